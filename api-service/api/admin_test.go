@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/pkg/tracing/tracingtest"
+)
+
+func TestAdminLoginComparesPassword(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	o := newOAuthTest(t)
+
+	login := func(email, password string) int {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+		req, _ := http.NewRequest(http.MethodPost, o.server.URL+"/v1/admins/login", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		return o.do(req).StatusCode
+	}
+
+	// every failure compares a password, the timing tells neither accounts nor admins apart
+	for _, tt := range []struct{ name, email, password string }{
+		{name: "non admin with the right password", email: "user@example.com", password: testUserPassword},
+		{name: "non admin with a wrong password", email: "user@example.com", password: "wrong_password"},
+		{name: "unknown email", email: "nobody@example.com", password: testUserPassword},
+	} {
+		exporter.Reset()
+		if status := login(tt.email, tt.password); status != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, http.StatusUnauthorized)
+		}
+
+		compared := false
+		for _, span := range exporter.GetSpans() {
+			if span.Name == "bcrypt.compare" {
+				compared = true
+			}
+		}
+		if !compared {
+			t.Errorf("%s: the password was not compared", tt.name)
+		}
+	}
+}
+
+func TestAdminTokenAudiences(t *testing.T) {
+	o := newOAuthTest(t)
+
+	get := func(path, token string) int {
+		req, _ := http.NewRequest(http.MethodGet, o.server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return o.do(req).StatusCode
+	}
+	admin := o.token("admin", tokens.AudienceAdmin, tokens.ACRPassword, time.Now())
+
+	// admins need a single login for the routes they share with users
+	if status := get("/v1/oauth/authorize?"+authorizeParams().Encode(), admin); status != http.StatusOK {
+		t.Errorf("shared route with an admin token: status = %d, want %d", status, http.StatusOK)
+	}
+	if status := get("/v1/policies", admin); status != http.StatusOK {
+		t.Errorf("admin route with an admin token: status = %d, want %d", status, http.StatusOK)
+	}
+
+	// a user token is not accepted on admin only routes, even for an admin
+	user := o.token("admin", tokens.AudienceUser, tokens.ACRPassword, time.Now())
+	if status := get("/v1/policies", user); status != http.StatusUnauthorized {
+		t.Errorf("admin route with a user token: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/app"
+	l "medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/pkg/metrics"
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+// ADMIN LOGIN
+// @Router /v1/admins/login [POST]
+// @Summary ADMIN LOGIN
+// @Description Api for admin login, issued tokens are valid on every route admins may access in the tenant
+// @Tags ADMIN
+// @Accept json
+// @Produce json
+// @Param body body models.AdminLoginReq true "Admin credentials"
+// @Success 200 {object} models.TokenResp
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) AdminLogin(c *gin.Context) {
+	var body models.AdminLoginReq
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		h.Logger.Error("error while bind admin login body", l.Error(err))
+		return
+	}
+
+	user, err := h.UserStore.Get(c.Request.Context(), &entity.UserFilter{Email: body.Email})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
+		// the password is still compared so unknown emails are not answered faster
+		compareSecret(c.Request.Context(), dummySecretHash(), body.Password)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		h.Logger.Error("error while get admin", l.Error(err))
+		return
+	}
+	// the password is compared before the role so the timing does not tell admins apart
+	if err := compareSecret(c.Request.Context(), user.Password, body.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+	tenant := h.loginTenant(body.Tenant)
+	if role, ok := h.tenantRole(user, tenant); !ok || role != app.RoleAdmin {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		h.Logger.Warn("admin login attempt by non admin user")
+		return
+	}
+
+	h.JwtHandler = tokens.JwtHandler{
+		Sub:            user.ID,
+		Role:           app.RoleAdmin,
+		Tenant:         tenant,
+		Aud:            []string{tokens.AudienceAdmin},
+		AuthTime:       time.Now().Unix(),
+		Acr:            tokens.ACRPassword,
+		SigninKey:      h.Config.Token.SignInKey,
+		Log:            h.Logger,
+		Timeout:        int(h.Config.Token.AdminAccessTTL),
+		RefreshTimeout: int(h.Config.Token.RefreshTTL),
+		Iss:            c.ClientIP(),
+	}
+
+	access, refresh, err := generateJwt(c.Request.Context(), &h.JwtHandler)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		h.Logger.Error("error while generate JWT", l.Error(err))
+		return
+	}
+
+	// one refresh token is kept per user, admin tokens are accepted on the routes
+	// admins share with users so the replaced user session is not needed
+	err = h.UserStore.Update(c.Request.Context(), &entity.User{
+		ID:           user.ID,
+		RefreshToken: hashRefreshToken(refresh),
+	})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user with refresh token"})
+		h.Logger.Error("error while update user", l.Error(err))
+		return
+	}
+
+	metrics.TokensIssued.WithLabelValues("admin_login").Inc()
+	c.JSON(http.StatusOK, &models.TokenResp{
+		Access:  access,
+		Refresh: refresh,
+	})
+}
@@ -0,0 +1,231 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/middleware"
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	l "medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/usecase/authorization_code"
+)
+
+const (
+	responseTypeCode = "code"
+	consentApprove   = "approve"
+
+	oauthErrAccessDenied            = "access_denied"
+	oauthErrUnsupportedResponseType = "unsupported_response_type"
+)
+
+type authorizeRequest struct {
+	client              *entity.Client
+	redirectURI         string
+	scopes              []string
+	state               string
+	codeChallenge       string
+	codeChallengeMethod string
+	nonce               string
+}
+
+// AUTHORIZE
+// @Security BearerAuth
+// @Router /v1/oauth/authorize [GET]
+// @Summary AUTHORIZE
+// @Description Api for the OAuth2 authorization endpoint, redirects with a code when the user already consented, otherwise returns the consent to ask for
+// @Tags OAUTH
+// @Produce json
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string true "Opaque value echoed back to the client"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Param nonce query string false "OpenID Connect nonce echoed in the id_token"
+// @Success 200 {object} models.ConsentResp
+// @Success 302
+// @Failure 400 {object} models.OAuthError
+func (h HandlerV1) Authorize(c *gin.Context) {
+	req, ok := h.parseAuthorizeRequest(c, c.Query)
+	if !ok {
+		return
+	}
+
+	principal, ok := middleware.PrincipalFromContext(c)
+	if !ok || principal.UserID == "" {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidRequest, "user is not authenticated")
+		return
+	}
+
+	covered, err := h.Consent.Covers(c.Request.Context(), principal.UserID, req.client.ID, req.scopes)
+	if err != nil {
+		authorizeRedirectError(c, req, oauthErrServerError)
+		h.Logger.Error("error while check consent", l.Error(err))
+		return
+	}
+	if !covered {
+		c.JSON(http.StatusOK, &models.ConsentResp{
+			ClientID:   req.client.ID,
+			ClientName: req.client.Name,
+			Scopes:     req.scopes,
+			State:      req.state,
+		})
+		return
+	}
+
+	h.issueAuthorizationCode(c, req, principal)
+}
+
+// AUTHORIZE CONSENT
+// @Security BearerAuth
+// @Router /v1/oauth/authorize [POST]
+// @Summary AUTHORIZE CONSENT
+// @Description Api for recording the user decision on the consent and redirecting back to the client
+// @Tags OAUTH
+// @Accept x-www-form-urlencoded
+// @Param response_type formData string true "Must be code"
+// @Param client_id formData string true "Client ID"
+// @Param redirect_uri formData string true "Registered redirect URI"
+// @Param scope formData string false "Space separated scopes"
+// @Param state formData string true "Opaque value echoed back to the client"
+// @Param code_challenge formData string true "PKCE code challenge"
+// @Param code_challenge_method formData string true "Must be S256"
+// @Param nonce formData string false "OpenID Connect nonce echoed in the id_token"
+// @Param consent formData string true "approve or deny"
+// @Success 302
+// @Failure 400 {object} models.OAuthError
+func (h HandlerV1) AuthorizeConsent(c *gin.Context) {
+	req, ok := h.parseAuthorizeRequest(c, c.PostForm)
+	if !ok {
+		return
+	}
+
+	principal, ok := middleware.PrincipalFromContext(c)
+	if !ok || principal.UserID == "" {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidRequest, "user is not authenticated")
+		return
+	}
+
+	if c.PostForm("consent") != consentApprove {
+		authorizeRedirectError(c, req, oauthErrAccessDenied)
+		return
+	}
+
+	err := h.Consent.Grant(c.Request.Context(), &entity.Consent{
+		UserID:   principal.UserID,
+		ClientID: req.client.ID,
+		Scopes:   req.scopes,
+	})
+	if err != nil {
+		authorizeRedirectError(c, req, oauthErrServerError)
+		h.Logger.Error("error while grant consent", l.Error(err))
+		return
+	}
+
+	h.issueAuthorizationCode(c, req, principal)
+}
+
+// parseAuthorizeRequest writes the error response itself, errors are only
+// redirected to the client once the redirect uri is known to be registered
+func (h HandlerV1) parseAuthorizeRequest(c *gin.Context, param func(string) string) (*authorizeRequest, bool) {
+	clientID := param("client_id")
+	if clientID == "" {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidRequest, "client_id is required")
+		return nil, false
+	}
+	client, err := h.Client.Get(c.Request.Context(), clientID)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidClient, "unknown client")
+		return nil, false
+	}
+
+	req := &authorizeRequest{
+		client:              client,
+		redirectURI:         param("redirect_uri"),
+		scopes:              strings.Fields(param("scope")),
+		state:               param("state"),
+		codeChallenge:       param("code_challenge"),
+		codeChallengeMethod: param("code_challenge_method"),
+		nonce:               param("nonce"),
+	}
+	if !containsString(client.RedirectURIs, req.redirectURI) {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidRequest, "redirect_uri is not registered for the client")
+		return nil, false
+	}
+
+	if param("response_type") != responseTypeCode {
+		authorizeRedirectError(c, req, oauthErrUnsupportedResponseType)
+		return nil, false
+	}
+	if req.state == "" {
+		authorizeRedirectError(c, req, oauthErrInvalidRequest)
+		return nil, false
+	}
+	if req.codeChallenge == "" || req.codeChallengeMethod != authorization_code.CodeChallengeMethodS256 {
+		authorizeRedirectError(c, req, oauthErrInvalidRequest)
+		return nil, false
+	}
+
+	if len(req.scopes) == 0 {
+		req.scopes = client.Scopes
+	}
+	for _, scope := range req.scopes {
+		if !containsString(client.Scopes, scope) {
+			authorizeRedirectError(c, req, oauthErrInvalidScope)
+			return nil, false
+		}
+	}
+
+	return req, true
+}
+
+func (h HandlerV1) issueAuthorizationCode(c *gin.Context, req *authorizeRequest, principal *middleware.Principal) {
+	code, err := h.AuthorizationCode.Create(c.Request.Context(), &entity.AuthorizationCode{
+		ClientID:            req.client.ID,
+		UserID:              principal.UserID,
+		Tenant:              principal.Tenant,
+		RedirectURI:         req.redirectURI,
+		Scopes:              req.scopes,
+		CodeChallenge:       req.codeChallenge,
+		CodeChallengeMethod: req.codeChallengeMethod,
+		Nonce:               req.nonce,
+		AuthTime:            principal.AuthTime,
+		ExpiresAt:           time.Now().UTC().Add(h.Config.Token.AuthCodeTTL),
+	})
+	if err != nil {
+		authorizeRedirectError(c, req, oauthErrServerError)
+		h.Logger.Error("error while create authorization code", l.Error(err))
+		return
+	}
+
+	authorizeRedirect(c, req, url.Values{"code": {code}})
+}
+
+func authorizeRedirectError(c *gin.Context, req *authorizeRequest, code string) {
+	authorizeRedirect(c, req, url.Values{"error": {code}})
+}
+
+func authorizeRedirect(c *gin.Context, req *authorizeRequest, params url.Values) {
+	u, err := url.Parse(req.redirectURI)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidRequest, "redirect_uri is malformed")
+		return
+	}
+
+	q := u.Query()
+	for key, values := range params {
+		q[key] = values
+	}
+	if req.state != "" {
+		q.Set("state", req.state)
+	}
+	u.RawQuery = q.Encode()
+
+	c.Redirect(http.StatusFound, u.String())
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/pkg/tracing"
+)
+
+// bcrypt and signing dominate the latency of logins, they get their own spans
+
+func hashSecret(ctx context.Context, secret string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	tracing.End(span, err)
+	return string(hash), err
+}
+
+func compareSecret(ctx context.Context, hash, secret string) error {
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret))
+	tracing.End(span, err)
+	return err
+}
+
+// dummySecretHash is compared against when there is no account, so failed
+// logins take the same time whether the account exists or not
+var dummySecretHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy secret"), bcrypt.DefaultCost)
+	return string(hash)
+})
+
+// refresh tokens are jwts whose first 72 bytes, all that bcrypt reads, are the
+// same for every token, they are stored as a sha-256 digest instead
+
+var errRefreshTokenMismatch = errors.New("refresh token does not match")
+
+func hashRefreshToken(refresh string) string {
+	sum := sha256.Sum256([]byte(refresh))
+	return hex.EncodeToString(sum[:])
+}
+
+func compareRefreshToken(hash, refresh string) error {
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashRefreshToken(refresh))) != 1 {
+		return errRefreshTokenMismatch
+	}
+	return nil
+}
+
+func generateJwt(ctx context.Context, jwtHandler *tokens.JwtHandler) (access, refresh string, err error) {
+	_, span := tracing.Start(ctx, "jwt.sign")
+	access, refresh, err = jwtHandler.GenerateJwt()
+	tracing.End(span, err)
+	return access, refresh, err
+}
+
+func generateClientJwt(ctx context.Context, jwtHandler *tokens.JwtHandler) (string, error) {
+	_, span := tracing.Start(ctx, "jwt.sign")
+	access, err := jwtHandler.GenerateClientJwt()
+	tracing.End(span, err)
+	return access, err
+}
@@ -8,39 +8,70 @@ import (
 
 	grpcClients "medods/api-service/internal/infrastructure/grpc_service_client"
 	"medods/api-service/internal/pkg/config"
+	"medods/api-service/internal/pkg/health"
+	"medods/api-service/internal/pkg/notify"
 	tokens "medods/api-service/internal/pkg/token"
 
 	appV "medods/api-service/internal/usecase/app_version"
+	"medods/api-service/internal/usecase/authorization_code"
+	"medods/api-service/internal/usecase/client"
+	"medods/api-service/internal/usecase/consent"
+	passwordReset "medods/api-service/internal/usecase/password_reset"
+	"medods/api-service/internal/usecase/user"
 )
 
 type HandlerV1 struct {
-	Config         *config.Config
-	Logger         *zap.Logger
-	ContextTimeout time.Duration
-	JwtHandler     tokens.JwtHandler
-	Service        grpcClients.ServiceClient
-	AppVersion     appV.AppVersion
-	Enforcer       *casbin.Enforcer
+	Config            *config.Config
+	Logger            *zap.Logger
+	ContextTimeout    time.Duration
+	JwtHandler        tokens.JwtHandler
+	Service           grpcClients.ServiceClient
+	UserStore         user.UserStore
+	AppVersion        appV.AppVersion
+	PasswordReset     passwordReset.PasswordReset
+	Client            client.Client
+	AuthorizationCode authorization_code.AuthorizationCode
+	Consent           consent.Consent
+	OIDCKey           *tokens.OIDCKey
+	Enforcer          *casbin.CachedEnforcer
+	Health            *health.Health
+	Notifier          *notify.Notifier
 }
 
 type HandlerV1Config struct {
-	Config         *config.Config
-	Logger         *zap.Logger
-	ContextTimeout time.Duration
-	JwtHandler     tokens.JwtHandler
-	Service        grpcClients.ServiceClient
-	AppVersion     appV.AppVersion
-	Enforcer       *casbin.Enforcer
+	Config            *config.Config
+	Logger            *zap.Logger
+	ContextTimeout    time.Duration
+	JwtHandler        tokens.JwtHandler
+	Service           grpcClients.ServiceClient
+	UserStore         user.UserStore
+	AppVersion        appV.AppVersion
+	PasswordReset     passwordReset.PasswordReset
+	Client            client.Client
+	AuthorizationCode authorization_code.AuthorizationCode
+	Consent           consent.Consent
+	OIDCKey           *tokens.OIDCKey
+	Enforcer          *casbin.CachedEnforcer
+	Health            *health.Health
+	Notifier          *notify.Notifier
 }
 
 func New(c *HandlerV1Config) *HandlerV1 {
 	return &HandlerV1{
-		Config:         c.Config,
-		Logger:         c.Logger,
-		ContextTimeout: c.ContextTimeout,
-		Service:        c.Service,
-		JwtHandler:     c.JwtHandler,
-		AppVersion:     c.AppVersion,
-		Enforcer:       c.Enforcer,
+		Config:            c.Config,
+		Logger:            c.Logger,
+		ContextTimeout:    c.ContextTimeout,
+		Service:           c.Service,
+		UserStore:         c.UserStore,
+		JwtHandler:        c.JwtHandler,
+		AppVersion:        c.AppVersion,
+		PasswordReset:     c.PasswordReset,
+		Client:            c.Client,
+		AuthorizationCode: c.AuthorizationCode,
+		Consent:           c.Consent,
+		OIDCKey:           c.OIDCKey,
+		Enforcer:          c.Enforcer,
+		Health:            c.Health,
+		Notifier:          c.Notifier,
 	}
 }
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/models"
+	"medods/api-service/internal/pkg/health"
+)
+
+// HEALTH
+// @Router /healthz [GET]
+// @Summary HEALTH
+// @Description Liveness probe, the process is alive when it answers
+// @Tags HEALTH
+// @Produce json
+// @Success 200 {object} models.HealthResp
+func (h HandlerV1) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, &models.HealthResp{Status: health.StatusUp})
+}
+
+// READY
+// @Router /readyz [GET]
+// @Summary READY
+// @Description Readiness probe, checks postgres, redis, the user service and the casbin policy
+// @Tags HEALTH
+// @Produce json
+// @Success 200 {object} models.ReadyResp
+// @Failure 503 {object} models.ReadyResp
+func (h HandlerV1) Readyz(c *gin.Context) {
+	ready, dependencies := h.Health.Ready(c.Request.Context())
+
+	resp := &models.ReadyResp{Status: health.StatusUp, Dependencies: dependencies}
+	if !ready {
+		resp.Status = health.StatusDown
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	l "medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/pkg/metrics"
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeAuthorizationCode = "authorization_code"
+
+	oauthErrInvalidRequest         = "invalid_request"
+	oauthErrInvalidClient          = "invalid_client"
+	oauthErrInvalidGrant           = "invalid_grant"
+	oauthErrInvalidScope           = "invalid_scope"
+	oauthErrUnsupportedGrantType   = "unsupported_grant_type"
+	oauthErrServerError            = "server_error"
+	oauthErrTemporarilyUnavailable = "temporarily_unavailable"
+)
+
+// oauthError asks clients failing to authenticate to the token endpoint for basic
+// credentials, and users failing to authenticate elsewhere for a bearer token
+func oauthError(c *gin.Context, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		if code == oauthErrInvalidClient {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		} else {
+			c.Header("WWW-Authenticate", `Bearer realm="oauth"`)
+		}
+	}
+	c.JSON(status, &models.OAuthError{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// OAUTH TOKEN
+// @Router /v1/oauth/token [POST]
+// @Summary OAUTH TOKEN
+// @Description Api for the OAuth2 token endpoint, supports the client_credentials and authorization_code (with PKCE) grants
+// @Tags OAUTH
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Grant type"
+// @Param client_id formData string false "Client ID, if HTTP Basic authentication is not used"
+// @Param client_secret formData string false "Client secret, if HTTP Basic authentication is not used"
+// @Param scope formData string false "Space separated scopes"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Success 200 {object} models.OAuthTokenResp
+// @Failure 400 {object} models.OAuthError
+// @Failure 401 {object} models.OAuthError
+// @Failure 500 {object} models.OAuthError
+func (h HandlerV1) OAuthToken(c *gin.Context) {
+	switch c.PostForm("grant_type") {
+	case grantTypeClientCredentials:
+		h.clientCredentialsGrant(c)
+	case grantTypeAuthorizationCode:
+		h.authorizationCodeGrant(c)
+	case "":
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidRequest, "grant_type is required")
+	default:
+		oauthError(c, http.StatusBadRequest, oauthErrUnsupportedGrantType, "")
+	}
+}
+
+func (h HandlerV1) clientCredentialsGrant(c *gin.Context) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidClient, "client authentication is required")
+		return
+	}
+
+	client, err := h.Client.Authenticate(c.Request.Context(), clientID, clientSecret)
+	if err != nil {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidClient, "")
+		h.Logger.Warn("client authentication failed", l.Error(err))
+		return
+	}
+
+	scopes := client.Scopes
+	if requested := strings.Fields(c.PostForm("scope")); len(requested) != 0 {
+		for _, scope := range requested {
+			if !containsString(client.Scopes, scope) {
+				oauthError(c, http.StatusBadRequest, oauthErrInvalidScope, "scope "+scope+" is not allowed for the client")
+				return
+			}
+		}
+		scopes = requested
+	}
+	// the token is only accepted by the apis its scopes name
+	audiences := tokens.ScopeAudiences(scopes)
+	if len(audiences) == 0 {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidScope, "scope must include "+tokens.AudienceUser+" or "+tokens.AudienceAdmin)
+		return
+	}
+
+	// the client acts in the tenant it is registered in
+	tenant := client.Tenant
+	if tenant == "" {
+		tenant = h.Config.Tenant.Default
+	}
+
+	// client tokens are not tied to a user facing app, casbin policies of the client subject decide access
+	jwtHandler := tokens.JwtHandler{
+		ClientID:  client.ID,
+		Scopes:    scopes,
+		Tenant:    tenant,
+		Aud:       audiences,
+		SigninKey: h.Config.Token.SignInKey,
+		Log:       h.Logger,
+		Timeout:   int(h.Config.Token.ClientAccessTTL),
+	}
+
+	access, err := generateClientJwt(c.Request.Context(), &jwtHandler)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, oauthErrServerError, "")
+		h.Logger.Error("error while generate client JWT", l.Error(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	metrics.TokensIssued.WithLabelValues("client_credentials").Inc()
+	c.JSON(http.StatusOK, &models.OAuthTokenResp{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.Config.Token.ClientAccessTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+func (h HandlerV1) authorizationCodeGrant(c *gin.Context) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidClient, "client_id is required")
+		return
+	}
+
+	client, err := h.Client.Get(c.Request.Context(), clientID)
+	if err != nil {
+		oauthError(c, http.StatusUnauthorized, oauthErrInvalidClient, "")
+		return
+	}
+	// public clients such as SPAs have no secret and rely on PKCE alone
+	if client.SecretHash != "" {
+		if _, err := h.Client.Authenticate(c.Request.Context(), clientID, clientSecret); err != nil {
+			oauthError(c, http.StatusUnauthorized, oauthErrInvalidClient, "")
+			h.Logger.Warn("client authentication failed", l.Error(err))
+			return
+		}
+	}
+
+	code, err := h.AuthorizationCode.Exchange(c.Request.Context(),
+		c.PostForm("code"),
+		client.ID,
+		c.PostForm("redirect_uri"),
+		c.PostForm("code_verifier"),
+	)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidGrant, err.Error())
+		return
+	}
+
+	user, err := h.UserStore.Get(c.Request.Context(), &entity.UserFilter{ID: code.UserID})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			oauthError(c, http.StatusServiceUnavailable, oauthErrTemporarilyUnavailable, "")
+			h.Logger.Error("user service is unavailable", l.Error(err))
+			return
+		}
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidGrant, "user not found")
+		h.Logger.Error("error while get user", l.Error(err))
+		return
+	}
+
+	role, ok := h.tenantRole(user, code.Tenant)
+	if !ok {
+		oauthError(c, http.StatusBadRequest, oauthErrInvalidGrant, "user is not a member of the tenant")
+		return
+	}
+
+	jwtHandler := tokens.JwtHandler{
+		Sub:            user.ID,
+		Role:           role,
+		Tenant:         code.Tenant,
+		Aud:            []string{tokens.AudienceUser},
+		ClientID:       client.ID,
+		Scopes:         code.Scopes,
+		AuthTime:       code.AuthTime.Unix(),
+		Acr:            tokens.ACRPassword,
+		SigninKey:      h.Config.Token.SignInKey,
+		Log:            h.Logger,
+		Timeout:        int(h.Config.Token.AccessTTL),
+		RefreshTimeout: int(h.Config.Token.RefreshTTL),
+		Iss:            c.ClientIP(),
+	}
+
+	access, refresh, err := generateJwt(c.Request.Context(), &jwtHandler)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, oauthErrServerError, "")
+		h.Logger.Error("error while generate JWT", l.Error(err))
+		return
+	}
+
+	idToken, err := h.generateIDToken(user, code)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, oauthErrServerError, "")
+		h.Logger.Error("error while generate id token", l.Error(err))
+		return
+	}
+
+	err = h.UserStore.Update(c.Request.Context(), &entity.User{
+		ID:           user.ID,
+		RefreshToken: hashRefreshToken(refresh),
+	})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			oauthError(c, http.StatusServiceUnavailable, oauthErrTemporarilyUnavailable, "")
+			h.Logger.Error("user service is unavailable", l.Error(err))
+			return
+		}
+		oauthError(c, http.StatusInternalServerError, oauthErrServerError, "")
+		h.Logger.Error("error while update user", l.Error(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	metrics.TokensIssued.WithLabelValues("authorization_code").Inc()
+	c.JSON(http.StatusOK, &models.OAuthTokenResp{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.Config.Token.AccessTTL.Seconds()),
+		RefreshToken: refresh,
+		Scope:        strings.Join(code.Scopes, " "),
+		IDToken:      idToken,
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
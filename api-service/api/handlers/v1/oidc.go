@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/middleware"
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	l "medods/api-service/internal/pkg/logger"
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/usecase/authorization_code"
+)
+
+const (
+	scopeOpenID  = "openid"
+	scopeProfile = "profile"
+	scopeEmail   = "email"
+	scopePhone   = "phone"
+)
+
+// OPENID CONFIGURATION
+// @Router /.well-known/openid-configuration [GET]
+// @Summary OPENID CONFIGURATION
+// @Description Api for OpenID Connect discovery
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} models.OpenIDConfiguration
+func (h HandlerV1) OpenIDConfiguration(c *gin.Context) {
+	issuer := h.Config.Token.Issuer
+
+	c.JSON(http.StatusOK, &models.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/v1/oauth/authorize",
+		TokenEndpoint:                     issuer + "/v1/oauth/token",
+		UserinfoEndpoint:                  issuer + "/v1/oauth/userinfo",
+		JwksURI:                           issuer + "/v1/oauth/jwks",
+		ScopesSupported:                   []string{scopeOpenID, scopeProfile, scopeEmail, scopePhone},
+		ResponseTypesSupported:            []string{responseTypeCode},
+		GrantTypesSupported:               []string{grantTypeAuthorizationCode, grantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{tokens.IDTokenSigningAlg},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{authorization_code.CodeChallengeMethodS256},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "acr", "azp",
+			"name", "gender", "birthdate", "email", "phone_number",
+		},
+	})
+}
+
+// JWKS
+// @Router /v1/oauth/jwks [GET]
+// @Summary JWKS
+// @Description Api for the public keys id tokens are signed with
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+func (h HandlerV1) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.OIDCKey.JWKS())
+}
+
+// USERINFO
+// @Security BearerAuth
+// @Router /v1/oauth/userinfo [GET]
+// @Summary USERINFO
+// @Description Api for the OpenID Connect userinfo, returned claims depend on the scopes of the access token
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.OAuthError
+// @Failure 403 {object} models.OAuthError
+func (h HandlerV1) UserInfo(c *gin.Context) {
+	principal, ok := middleware.PrincipalFromContext(c)
+	if !ok || principal.UserID == "" {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, &models.OAuthError{Error: "invalid_token"})
+		return
+	}
+
+	scopes := principal.Scopes
+	if !containsString(scopes, scopeOpenID) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		c.JSON(http.StatusForbidden, &models.OAuthError{Error: "insufficient_scope"})
+		return
+	}
+
+	user, err := h.UserStore.Get(c.Request.Context(), &entity.UserFilter{ID: principal.UserID})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, &models.OAuthError{Error: "invalid_token"})
+		h.Logger.Error("error while get user", l.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfoClaims(user, scopes))
+}
+
+// generateIDToken returns an empty token when the openid scope was not granted
+func (h HandlerV1) generateIDToken(user *entity.User, code *entity.AuthorizationCode) (string, error) {
+	if !containsString(code.Scopes, scopeOpenID) {
+		return "", nil
+	}
+
+	claims := userInfoClaims(user, code.Scopes)
+	claims["iss"] = h.Config.Token.Issuer
+	claims["aud"] = code.ClientID
+	claims["azp"] = code.ClientID
+	claims["exp"] = time.Now().Add(h.Config.Token.AccessTTL).Unix()
+	claims["iat"] = time.Now().Unix()
+	claims["auth_time"] = code.AuthTime.Unix()
+	claims["acr"] = tokens.ACRPassword
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+
+	return h.OIDCKey.SignIDToken(claims)
+}
+
+// userInfoClaims maps the user to the standard claims released by the granted scopes
+func userInfoClaims(user *entity.User, scopes []string) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+	}
+
+	if containsString(scopes, scopeProfile) {
+		claims["name"] = user.FullName
+		claims["gender"] = user.Gender
+		claims["birthdate"] = user.DateOfBirth
+		claims["picture"] = user.ProfileImg
+	}
+	if containsString(scopes, scopeEmail) {
+		claims["email"] = user.Email
+	}
+	if containsString(scopes, scopePhone) {
+		claims["phone_number"] = user.PhoneNumber
+	}
+
+	return claims
+}
@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	errorspkg "medods/api-service/internal/errors"
+	l "medods/api-service/internal/pkg/logger"
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+const (
+	minPasswordLength = 8
+	// user-service treats empty fields as "not changed" on Update, so a
+	// revoked refresh token is replaced with a value that is never a valid bcrypt hash
+	revokedRefreshToken = "revoked"
+	resetRequestedMsg   = "If the email is registered, a password reset link has been sent"
+)
+
+// REQUEST PASSWORD RESET
+// @Router /v1/users/set/{email} [GET]
+// @Summary REQUEST PASSWORD RESET
+// @Description Api for requesting a password reset email, the response does not reveal whether the email is registered
+// @Tags PASSWORD
+// @Accept json
+// @Produce json
+// @Param email path string true "Email"
+// @Success 200 {object} models.MessageResp
+// @Failure 400 {object} models.StandartError
+func (h HandlerV1) RequestPasswordReset(c *gin.Context) {
+	email := c.Param("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	// the reset is prepared in the background, the response neither says nor
+	// takes longer when the email is registered
+	ctx := context.WithoutCancel(c.Request.Context())
+	h.Notifier.Go("password reset email", func() error {
+		ctx, cancel := context.WithTimeout(ctx, h.ContextTimeout)
+		defer cancel()
+		return h.sendPasswordReset(ctx, email)
+	})
+
+	c.JSON(http.StatusOK, &models.MessageResp{Message: resetRequestedMsg})
+}
+
+// sendPasswordReset mails a reset token to the user with the email, unknown emails are ignored
+func (h HandlerV1) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := h.UserStore.Get(ctx, &entity.UserFilter{Email: email})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			return fmt.Errorf("get user: %w", err)
+		}
+		h.Logger.Debug("password reset requested for unknown email", l.Error(err))
+		return nil
+	}
+
+	reset := &entity.PasswordReset{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(h.Config.Token.ResetTTL),
+	}
+	if err := h.PasswordReset.Create(ctx, reset); err != nil {
+		return fmt.Errorf("create password reset: %w", err)
+	}
+
+	jwtHandler := tokens.JwtHandler{
+		Sub:       user.ID,
+		SigninKey: h.Config.Token.SignInKey,
+		Log:       h.Logger,
+	}
+	resetToken, err := jwtHandler.GenerateResetJwt(reset.GUID, h.Config.Token.ResetTTL)
+	if err != nil {
+		return fmt.Errorf("generate reset token: %w", err)
+	}
+
+	return sendEmail(ctx, user.Email, "Password reset", "Use this token to reset your password: "+resetToken)
+}
+
+// RESET PASSWORD
+// @Router /v1/users/password [PUT]
+// @Summary RESET PASSWORD
+// @Description Api for setting a new password with a single-use reset token, all refresh tokens of the user are revoked
+// @Tags PASSWORD
+// @Accept json
+// @Produce json
+// @Param body body models.ResetPasswordReq true "Reset password"
+// @Success 200 {object} models.MessageResp
+// @Failure 400 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) ResetPassword(c *gin.Context) {
+	var body models.ResetPasswordReq
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		h.Logger.Error("error while bind reset password body", l.Error(err))
+		return
+	}
+	if len(body.Password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password is too short"})
+		return
+	}
+
+	claims, err := tokens.ExtractResetClaim(body.Token, h.Config.Token.SignInKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+		return
+	}
+
+	reset, err := h.PasswordReset.Use(c.Request.Context(), cast.ToString(claims["jti"]))
+	if err != nil {
+		if errors.Is(err, errorspkg.ErrorNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		h.Logger.Error("error while use password reset", l.Error(err))
+		return
+	}
+	if reset.UserID != cast.ToString(claims["sub"]) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+		h.Logger.Warn("password reset subject mismatch")
+		return
+	}
+
+	hashPassword, err := hashSecret(c.Request.Context(), body.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		h.Logger.Error("error while hash password", l.Error(err))
+		return
+	}
+
+	err = h.UserStore.Update(c.Request.Context(), &entity.User{
+		ID:           reset.UserID,
+		Password:     hashPassword,
+		RefreshToken: revokedRefreshToken,
+	})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		h.Logger.Error("error while update user password", l.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.MessageResp{Message: "Password has been reset"})
+}
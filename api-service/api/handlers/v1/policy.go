@@ -0,0 +1,337 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/models"
+	l "medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/pkg/policy"
+)
+
+// LIST POLICIES
+// @Security BearerAuth
+// @Router /v1/policies [GET]
+// @Summary LIST POLICIES
+// @Description Api for listing permission (p) rules of the caller tenant and the rules shared by every tenant
+// @Tags POLICY
+// @Produce json
+// @Success 200 {object} models.PolicyRules
+// @Failure 401 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) ListPolicies(c *gin.Context) {
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+
+	res := &models.PolicyRules{Rules: []*models.PolicyRule{}}
+	for _, t := range []string{policy.AnyTenant, tenant} {
+		policies, err := h.Enforcer.GetFilteredPolicy(1, t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get policies"})
+			h.Logger.Error("error while get policies", l.Error(err))
+			return
+		}
+
+		for _, p := range policies {
+			if len(p) < 4 {
+				continue
+			}
+			res.Rules = append(res.Rules, &models.PolicyRule{
+				Subject: p[0],
+				Tenant:  p[1],
+				Object:  p[2],
+				Action:  p[3],
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ADD POLICY
+// @Security BearerAuth
+// @Router /v1/policies [POST]
+// @Summary ADD POLICY
+// @Description Api for adding a permission (p) rule to the caller tenant, the change is persisted and broadcast to all replicas
+// @Tags POLICY
+// @Accept json
+// @Produce json
+// @Param body body models.PolicyRule true "Policy rule"
+// @Success 201 {object} models.PolicyRule
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 409 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) AddPolicy(c *gin.Context) {
+	var body models.PolicyRule
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject, object and action are required"})
+		return
+	}
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+	body.Tenant = tenant
+
+	added, err := h.Enforcer.AddPolicy(body.Subject, body.Tenant, body.Object, body.Action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add policy"})
+		h.Logger.Error("error while add policy", l.Error(err))
+		return
+	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{"error": "policy already exists"})
+		return
+	}
+	h.invalidatePolicyCache()
+
+	c.JSON(http.StatusCreated, &body)
+}
+
+// REMOVE POLICY
+// @Security BearerAuth
+// @Router /v1/policies [DELETE]
+// @Summary REMOVE POLICY
+// @Description Api for removing a permission (p) rule of the caller tenant, the change is persisted and broadcast to all replicas
+// @Tags POLICY
+// @Accept json
+// @Produce json
+// @Param body body models.PolicyRule true "Policy rule"
+// @Success 200 {object} models.MessageResp
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 404 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) RemovePolicy(c *gin.Context) {
+	var body models.PolicyRule
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject, object and action are required"})
+		return
+	}
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.Enforcer.RemovePolicy(body.Subject, tenant, body.Object, body.Action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove policy"})
+		h.Logger.Error("error while remove policy", l.Error(err))
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "policy not found"})
+		return
+	}
+	h.invalidatePolicyCache()
+
+	c.JSON(http.StatusOK, &models.MessageResp{Message: "Policy has been removed"})
+}
+
+// LIST ROLE RULES
+// @Security BearerAuth
+// @Router /v1/policies/roles [GET]
+// @Summary LIST ROLE RULES
+// @Description Api for listing role inheritance (g) rules of the caller tenant and the rules shared by every tenant
+// @Tags POLICY
+// @Produce json
+// @Success 200 {object} models.RoleRules
+// @Failure 401 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) ListRoleRules(c *gin.Context) {
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+
+	res := &models.RoleRules{Rules: []*models.RoleRule{}}
+	for _, t := range []string{policy.AnyTenant, tenant} {
+		rules, err := h.Enforcer.GetFilteredGroupingPolicy(2, t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get role rules"})
+			h.Logger.Error("error while get grouping policies", l.Error(err))
+			return
+		}
+
+		for _, g := range rules {
+			if len(g) < 3 {
+				continue
+			}
+			res.Rules = append(res.Rules, &models.RoleRule{
+				Subject: g[0],
+				Role:    g[1],
+				Tenant:  g[2],
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ADD ROLE RULE
+// @Security BearerAuth
+// @Router /v1/policies/roles [POST]
+// @Summary ADD ROLE RULE
+// @Description Api for assigning a role to a subject in the caller tenant, the change is persisted and broadcast to all replicas
+// @Tags POLICY
+// @Accept json
+// @Produce json
+// @Param body body models.RoleRule true "Role rule"
+// @Success 201 {object} models.RoleRule
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 409 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) AddRoleRule(c *gin.Context) {
+	var body models.RoleRule
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject and role are required"})
+		return
+	}
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+	body.Tenant = tenant
+
+	added, err := h.Enforcer.AddGroupingPolicy(body.Subject, body.Role, body.Tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add role rule"})
+		h.Logger.Error("error while add grouping policy", l.Error(err))
+		return
+	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{"error": "role rule already exists"})
+		return
+	}
+	h.invalidatePolicyCache()
+
+	c.JSON(http.StatusCreated, &body)
+}
+
+// REMOVE ROLE RULE
+// @Security BearerAuth
+// @Router /v1/policies/roles [DELETE]
+// @Summary REMOVE ROLE RULE
+// @Description Api for revoking a role from a subject in the caller tenant, the change is persisted and broadcast to all replicas
+// @Tags POLICY
+// @Accept json
+// @Produce json
+// @Param body body models.RoleRule true "Role rule"
+// @Success 200 {object} models.MessageResp
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 404 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) RemoveRoleRule(c *gin.Context) {
+	var body models.RoleRule
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject and role are required"})
+		return
+	}
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.Enforcer.RemoveGroupingPolicy(body.Subject, body.Role, tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove role rule"})
+		h.Logger.Error("error while remove grouping policy", l.Error(err))
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role rule not found"})
+		return
+	}
+	h.invalidatePolicyCache()
+
+	c.JSON(http.StatusOK, &models.MessageResp{Message: "Role rule has been removed"})
+}
+
+// SUBJECT ROLES
+// @Security BearerAuth
+// @Router /v1/policies/roles/{subject} [GET]
+// @Summary SUBJECT ROLES
+// @Description Api for listing the direct and inherited roles of a subject in the caller tenant
+// @Tags POLICY
+// @Produce json
+// @Param subject path string true "Subject"
+// @Success 200 {object} models.SubjectRoles
+// @Failure 401 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) SubjectRoles(c *gin.Context) {
+	subject := c.Param("subject")
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.Enforcer.GetImplicitRolesForUser(subject, tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get roles"})
+		h.Logger.Error("error while get implicit roles", l.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.SubjectRoles{
+		Subject: subject,
+		Tenant:  tenant,
+		Roles:   roles,
+	})
+}
+
+// CHECK POLICY
+// @Security BearerAuth
+// @Router /v1/policies/check [GET]
+// @Summary CHECK POLICY
+// @Description Api for a dry run of the enforcer in the caller tenant, tells whether the subject would be allowed to do the action on the object
+// @Tags POLICY
+// @Produce json
+// @Param subject query string true "Subject"
+// @Param subject_id query string false "Id of the subject user, matched against the owner of the object"
+// @Param object query string true "Object, the request path"
+// @Param action query string true "Action, the request method"
+// @Success 200 {object} models.PolicyCheckResp
+// @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
+// @Failure 500 {object} models.StandartError
+func (h HandlerV1) CheckPolicy(c *gin.Context) {
+	res := &models.PolicyCheckResp{
+		Subject:   c.Query("subject"),
+		SubjectID: c.Query("subject_id"),
+		Object:    c.Query("object"),
+		Action:    c.Query("action"),
+	}
+	if res.Subject == "" || res.Object == "" || res.Action == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject, object and action are required"})
+		return
+	}
+	tenant, ok := h.callerTenant(c)
+	if !ok {
+		return
+	}
+	res.Tenant = tenant
+
+	subject := policy.Subject{Role: res.Subject, Tenant: res.Tenant}
+	allowed, err := policy.Enforce(h.Enforcer, subject, policy.NewObject(res.Object, "", res.SubjectID), res.Action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check policy"})
+		h.Logger.Error("error while enforce", l.Error(err))
+		return
+	}
+	res.Allowed = allowed
+
+	c.JSON(http.StatusOK, res)
+}
+
+// invalidatePolicyCache drops cached decisions, other replicas reload through the watcher
+func (h HandlerV1) invalidatePolicyCache() {
+	if err := h.Enforcer.InvalidateCache(); err != nil {
+		h.Logger.Error("error while invalidate enforcer cache", l.Error(err))
+	}
+}
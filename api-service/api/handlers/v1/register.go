@@ -1,19 +1,23 @@
 package v1
 
 import (
+	"context"
 	"medods/api-service/api/models"
-	pbu "medods/api-service/genproto/user-proto"
+	"medods/api-service/internal/entity"
 	l "medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/pkg/metrics"
 	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/pkg/tracing"
 	"net/http"
 	"gopkg.in/gomail.v2"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/spf13/cast"
 )
 
-func sendWarningEmail(to string, subject string, body string) error {
+func sendEmail(ctx context.Context, to string, subject string, body string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", "avazbekbekmurodov1459@example.com")
 	m.SetHeader("To", to)
@@ -22,7 +26,11 @@ func sendWarningEmail(to string, subject string, body string) error {
 
 	d := gomail.NewDialer("smtp.example.com", 587, "avazbekbekmurodov1459@example.com", "Awez1459")
 
-	if err := d.DialAndSend(m); err != nil {
+	_, span := tracing.Start(ctx, "smtp.send")
+	err := d.DialAndSend(m)
+	tracing.End(span, err)
+	metrics.Emails.WithLabelValues(metrics.EmailResult(err)).Inc()
+	if err != nil {
 		return fmt.Errorf("failed to send email: %v", err)
 	}
 	return nil
@@ -37,8 +45,10 @@ func sendWarningEmail(to string, subject string, body string) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "ID"
+// @Param tenant query string false "Tenant, the default tenant when empty"
 // @Success 200 {object} models.TokenResp
 // @Failure 400 {object} models.StandartError
+// @Failure 401 {object} models.StandartError
 // @Failure 500 {object} models.StandartError
 func (h HandlerV1) Token(c *gin.Context) {
 	id := c.Param("id")
@@ -46,10 +56,12 @@ func (h HandlerV1) Token(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
-	user, err := h.Service.UserService().Get(c, &pbu.Filter{
-		Filter: map[string]string{"id": id},
-	})
+	user, err := h.UserStore.Get(c.Request.Context(), &entity.UserFilter{ID: id})
 	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "user not found",
 		})
@@ -57,18 +69,30 @@ func (h HandlerV1) Token(c *gin.Context) {
 		return
 	}
 
+	tenant := h.loginTenant(c.Query("tenant"))
+	role, ok := h.tenantRole(user, tenant)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user is not a member of the tenant"})
+		return
+	}
+
 	clientIP := c.ClientIP()
 
 	h.JwtHandler = tokens.JwtHandler{
-		Sub:       user.User.Id,
-		Role:      user.User.Role,
-		SigninKey: h.Config.Token.SignInKey,
-		Log:       h.Logger,
-		Timeout:   int(h.Config.Token.AccessTTL),
-		Iss:       clientIP,
+		Sub:            user.ID,
+		Role:           role,
+		Tenant:         tenant,
+		Aud:            []string{tokens.AudienceUser},
+		AuthTime:       time.Now().Unix(),
+		Acr:            tokens.ACRPassword,
+		SigninKey:      h.Config.Token.SignInKey,
+		Log:            h.Logger,
+		Timeout:        int(h.Config.Token.AccessTTL),
+		RefreshTimeout: int(h.Config.Token.RefreshTTL),
+		Iss:            clientIP,
 	}
 
-	access, refresh, err := h.JwtHandler.GenerateJwt()
+	access, refresh, err := generateJwt(c.Request.Context(), &h.JwtHandler)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate tokens",
@@ -77,18 +101,15 @@ func (h HandlerV1) Token(c *gin.Context) {
 		return
 	}
 
-	hashRefresh, err := bcrypt.GenerateFromPassword([]byte(refresh), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash refresh token"})
-		h.Logger.Error("error while hash refresh", l.Error(err))
-		return
-	}
-
-	_, err = h.Service.UserService().Update(c, &pbu.User{
-		Id:           user.User.Id,
-		RefreshToken: string(hashRefresh),
+	err = h.UserStore.Update(c.Request.Context(), &entity.User{
+		ID:           user.ID,
+		RefreshToken: hashRefreshToken(refresh),
 	})
 	if err != nil {
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update user with refresh token",
 		})
@@ -96,6 +117,7 @@ func (h HandlerV1) Token(c *gin.Context) {
 		return
 	}
 
+	metrics.TokensIssued.WithLabelValues("login").Inc()
 	c.JSON(http.StatusOK, &models.TokenResp{
 		Access:  access,
 		Refresh: refresh,
@@ -121,10 +143,27 @@ func (h HandlerV1) UpdateToken(c *gin.Context) {
 		return
 	}
 
-	user, err := h.Service.UserService().Get(c, &pbu.Filter{
-		Filter: map[string]string{"refresh_token": refresh},
-	})
+	resClaim, err := tokens.ExtractClaim(refresh, []byte(h.Config.Token.SignInKey))
+	if err == nil && !tokens.HasType(resClaim, tokens.TypeRefresh) {
+		err = fmt.Errorf("token of type %q is not a refresh token", cast.ToString(resClaim["typ"]))
+	}
 	if err != nil {
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshInvalidToken).Inc()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reload Page",
+		})
+		h.Logger.Error("Failed to extract token update token", l.Error(err))
+		return
+	}
+	// only the hash of the refresh token is stored, the user is found by the subject of the token
+	user, err := h.UserStore.Get(c.Request.Context(), &entity.UserFilter{ID: cast.ToString(resClaim["sub"])})
+	if err != nil {
+		if userServiceUnavailable(err) {
+			metrics.RefreshFailures.WithLabelValues(metrics.RefreshInternal).Inc()
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshUnknownUser).Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "User not found",
 		})
@@ -132,8 +171,9 @@ func (h HandlerV1) UpdateToken(c *gin.Context) {
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.User.RefreshToken), []byte(refresh))
+	err = compareRefreshToken(user.RefreshToken, refresh)
 	if err != nil {
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshTokenReused).Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "refresh token not found",
 		})
@@ -142,52 +182,66 @@ func (h HandlerV1) UpdateToken(c *gin.Context) {
 	}
 
 	clientIP := c.ClientIP()
-	resClaim, err := tokens.ExtractClaim(refresh, []byte(h.Config.Token.SignInKey))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Reload Page",
-		})
-		h.Logger.Error("Failed to extract token update token", l.Error(err))
-		return
-	}
 	if resClaim["iss"] != clientIP {
 		h.Logger.Warn("IP address mismatch")
-		err := sendWarningEmail(user.User.Email, "IP address mismatch", "Your IP address mismatched.")
-		if err != nil {
-			h.Logger.Error("Failed to send warning email", l.Error(err))
-		}
+		metrics.IPMismatches.Inc()
+		// the warning is sent in the background so a slow smtp server does not block the refresh
+		ctx, to := context.WithoutCancel(c.Request.Context()), user.Email
+		h.Notifier.Go("ip mismatch email", func() error {
+			return sendEmail(ctx, to, "IP address mismatch", "Your IP address mismatched.")
+		})
 	}
 
+	// the role is resolved again so revoked tenant grants take effect on refresh
+	tenant := tokens.Tenant(resClaim, h.Config.Tenant.Default)
+	role, ok := h.tenantRole(user, tenant)
+	if !ok {
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshNotMember).Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user is not a member of the tenant"})
+		return
+	}
+
+	// refreshing does not re-authenticate the user, the original authentication is carried over
 	h.JwtHandler = tokens.JwtHandler{
-		Sub:       user.User.Id,
-		Role:      user.User.Role,
-		SigninKey: h.Config.Token.SignInKey,
-		Log:       h.Logger,
-		Timeout:   int(h.Config.Token.AccessTTL),
-		Iss:       clientIP,
+		Sub:            user.ID,
+		Role:           role,
+		Tenant:         tenant,
+		SessionID:      cast.ToString(resClaim["sid"]),
+		Aud:            []string{tokens.AudienceUser},
+		ClientID:       cast.ToString(resClaim["client_id"]),
+		Scopes:         tokens.Scopes(resClaim),
+		AuthTime:       cast.ToInt64(resClaim["auth_time"]),
+		Acr:            cast.ToString(resClaim["acr"]),
+		SigninKey:      h.Config.Token.SignInKey,
+		Log:            h.Logger,
+		Timeout:        int(h.Config.Token.AccessTTL),
+		RefreshTimeout: int(h.Config.Token.RefreshTTL),
+		Iss:            clientIP,
+	}
+	// admin sessions keep the admin audience and its shorter ttl
+	if tokens.HasAudience(resClaim, tokens.AudienceAdmin) {
+		h.JwtHandler.Aud = []string{tokens.AudienceAdmin}
+		h.JwtHandler.Timeout = int(h.Config.Token.AdminAccessTTL)
 	}
 
-	newAccess, newRefresh, err := h.JwtHandler.GenerateJwt()
+	newAccess, newRefresh, err := generateJwt(c.Request.Context(), &h.JwtHandler)
 	if err != nil {
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshInternal).Inc()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate new tokens"})
 		h.Logger.Error("error while generating new tokens", l.Error(err))
 		return
 	}
 
-	hashNewR, err := bcrypt.GenerateFromPassword([]byte(newRefresh), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to hash new refresh token",
-		})
-		h.Logger.Error("error while hash new refresh token", l.Error(err))
-		return
-	}
-
-	_, err = h.Service.UserService().Update(c, &pbu.User{
-		Id:           user.User.Id,
-		RefreshToken: string(hashNewR),
+	err = h.UserStore.Update(c.Request.Context(), &entity.User{
+		ID:           user.ID,
+		RefreshToken: hashRefreshToken(newRefresh),
 	})
 	if err != nil {
+		metrics.RefreshFailures.WithLabelValues(metrics.RefreshInternal).Inc()
+		if userServiceUnavailable(err) {
+			h.userServiceUnavailableResp(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to update user with new refresh token",
 		})
@@ -195,6 +249,8 @@ func (h HandlerV1) UpdateToken(c *gin.Context) {
 		return
 	}
 
+	metrics.Refreshes.Inc()
+	metrics.TokensIssued.WithLabelValues("refresh_token").Inc()
 	c.JSON(http.StatusOK, &models.TokenResp{
 		Access:  newAccess,
 		Refresh: newRefresh,
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/api/middleware"
+	"medods/api-service/internal/entity"
+)
+
+// tenantRole returns the role of the user in the tenant. A role granted in the
+// tenant wins, users of the default tenant without a grant keep the role of
+// their account and users without a grant elsewhere are not members.
+func (h HandlerV1) tenantRole(user *entity.User, tenant string) (string, bool) {
+	if roles := h.Enforcer.GetRolesForUserInDomain(user.ID, tenant); len(roles) != 0 {
+		return roles[0], true
+	}
+	if tenant == h.Config.Tenant.Default {
+		return user.Role, true
+	}
+	return "", false
+}
+
+// loginTenant returns the tenant asked for at login, the default tenant when it is empty
+func (h HandlerV1) loginTenant(tenant string) string {
+	if tenant == "" {
+		return h.Config.Tenant.Default
+	}
+	return tenant
+}
+
+// callerTenant returns the tenant of the caller, policies are only managed within it
+func (h HandlerV1) callerTenant(c *gin.Context) (string, bool) {
+	principal, ok := middleware.PrincipalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid access token"})
+		return "", false
+	}
+	return principal.Tenant, true
+}
@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	l "medods/api-service/internal/pkg/logger"
+)
+
+// userServiceUnavailable reports whether the user service could not be reached
+// or its circuit breaker is open, such errors are not the fault of the caller
+func userServiceUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+func (h HandlerV1) userServiceUnavailableResp(c *gin.Context, err error) {
+	c.Header("Retry-After", "5")
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "user service is unavailable"})
+	h.Logger.Error("user service is unavailable", l.Error(err))
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveParams are path and query parameters holding credentials
+var sensitiveParams = map[string]bool{
+	"refresh":       true,
+	"refresh_token": true,
+	"access_token":  true,
+	"id_token":      true,
+	"code":          true,
+	"code_verifier": true,
+	"client_secret": true,
+	"password":      true,
+	"token":         true,
+}
+
+// AccessLog writes one zap entry per request, it must run after RequestID.
+// Header values are never logged, so the Authorization header can not leak, and
+// credentials passed in the path or the query are redacted.
+func AccessLog(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		fields := []zap.Field{
+			zap.String("request_id", RequestIDFromContext(c)),
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.String("path", redactPath(c)),
+			zap.String("query", redactQuery(c.Request.URL.Query())),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", c.Writer.Size()),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+		}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+			fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+		}
+		if principal, ok := PrincipalFromContext(c); ok {
+			fields = append(fields, zap.String("user_id", principal.UserID), zap.String("client_id", principal.ClientID))
+		}
+		if len(c.Errors) != 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			logger.Error("request", fields...)
+		case status >= 400:
+			logger.Warn("request", fields...)
+		default:
+			logger.Info("request", fields...)
+		}
+	}
+}
+
+// redactPath replaces the values of sensitive path parameters
+func redactPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, param := range c.Params {
+		if sensitiveParams[param.Key] && param.Value != "" {
+			path = strings.Replace(path, param.Value, redacted, 1)
+		}
+	}
+	return path
+}
+
+// redactQuery replaces the values of sensitive query parameters
+func redactQuery(query url.Values) string {
+	for key := range query {
+		if sensitiveParams[strings.ToLower(key)] {
+			query[key] = []string{redacted}
+		}
+	}
+	raw := query.Encode()
+	return strings.ReplaceAll(raw, url.QueryEscape(redacted), redacted)
+}
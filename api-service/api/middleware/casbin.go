@@ -2,22 +2,39 @@ package middleware
 
 import (
 	"errors"
+	"medods/api-service/api/models"
+	"medods/api-service/internal/pkg/app"
 	"medods/api-service/internal/pkg/config"
+	"medods/api-service/internal/pkg/metrics"
+	"medods/api-service/internal/pkg/policy"
 	tokens "medods/api-service/internal/pkg/token"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/casbin/casbin/v2"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/cast"
 )
 
+var (
+	// errInvalidToken is returned when a route needs authentication and the token is missing, invalid or expired
+	errInvalidToken = errors.New("invalid token")
+	// errInsufficientScope is returned when a token issued to a client lacks the scope of the route
+	errInsufficientScope = errors.New("insufficient scope")
+)
+
+// routeScopes are the scopes client tokens need on routes that do not require the scope of their api
+var routeScopes = map[string]string{
+	"/v1/oauth/userinfo": "openid",
+}
+
 type JwtRoleAuth struct {
-	enforcer *casbin.Enforcer
+	enforcer *casbin.CachedEnforcer
 	cfg      config.Config
 }
 
-func CheckCasbinPermission(casbin *casbin.Enforcer, cfg config.Config) gin.HandlerFunc {
+func CheckCasbinPermission(casbin *casbin.CachedEnforcer, cfg config.Config) gin.HandlerFunc {
 	casbinHandler := &JwtRoleAuth{
 		cfg:      cfg,
 		enforcer: casbin,
@@ -25,62 +42,197 @@ func CheckCasbinPermission(casbin *casbin.Enforcer, cfg config.Config) gin.Handl
 
 	return func(c *gin.Context) {
 		allow, err := casbinHandler.CheckPermission(c)
-
+		if errors.Is(err, errInvalidToken) {
+			metrics.CasbinDenials.WithLabelValues(c.FullPath(), strconv.Itoa(http.StatusUnauthorized)).Inc()
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abortWithError(c, http.StatusUnauthorized, "Missing, invalid or expired access token")
+			return
+		}
+		if errors.Is(err, errInsufficientScope) {
+			metrics.CasbinDenials.WithLabelValues(c.FullPath(), strconv.Itoa(http.StatusForbidden)).Inc()
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+			abortWithError(c, http.StatusForbidden, "Access token lacks the scope of the route")
+			return
+		}
 		if err != nil {
-			c.AbortWithError(http.StatusUnauthorized, err)
+			abortWithError(c, http.StatusInternalServerError, "Failed to check permission")
+			return
 		}
 		if !allow {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "Permission denied",
-			})
+			metrics.CasbinDenials.WithLabelValues(c.FullPath(), strconv.Itoa(http.StatusForbidden)).Inc()
+			abortWithError(c, http.StatusForbidden, "Permission denied")
+			return
 		}
 	}
 
 }
 
+func abortWithError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, models.StandartError{
+		Error: models.Error{Message: message},
+	})
+}
+
 func (casb *JwtRoleAuth) GetRole(c *gin.Context) (string, int) {
+	subject, status := casb.GetSubject(c)
+	return subject.Role, status
+}
+
+// GetSubject returns the caller of the request, the id is only set for user tokens
+func (casb *JwtRoleAuth) GetSubject(c *gin.Context) (policy.Subject, int) {
+	principal, status := casb.GetPrincipal(c)
+	return casb.subject(principal), status
+}
+
+// GetPrincipal verifies the access token of the request, the principal is nil
+// with a 401 status when the token is missing, invalid, not an access token or
+// issued for another api, and with a 403 status when a token issued to a client
+// lacks the scope of the route
+func (casb *JwtRoleAuth) GetPrincipal(c *gin.Context) (*Principal, int) {
+	claims, err := casb.GetClaims(c)
+	if err != nil || !tokens.HasType(claims, tokens.TypeAccess) {
+		return nil, http.StatusUnauthorized
+	}
+	principal := NewPrincipal(claims, casb.cfg.Tenant.Default, c.ClientIP())
+
+	// a token is only accepted by the api it was issued for
+	audiences, err := casb.AcceptedAudiences(principal.Tenant, casb.object(c, principal), c.Request.Method)
+	if err != nil {
+		return nil, http.StatusUnauthorized
+	}
+	audience := ""
+	for _, accepted := range audiences {
+		if tokens.HasAudience(claims, accepted) {
+			audience = accepted
+			break
+		}
+	}
+	if audience == "" {
+		return nil, http.StatusUnauthorized
+	}
+	if principal.ClientID != "" && !containsScope(principal.Scopes, requiredScope(c.FullPath(), audience)) {
+		return nil, http.StatusForbidden
+	}
+	return principal, 0
+}
+
+// requiredScope returns the scope a client token needs on the route, the scope named after the api by default
+func requiredScope(route, audience string) string {
+	if scope, ok := routeScopes[route]; ok {
+		return scope
+	}
+	return audience
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// subject maps the principal to the casbin subject, service clients are matched by their client subject
+func (casb *JwtRoleAuth) subject(principal *Principal) policy.Subject {
+	switch {
+	case principal == nil:
+		return policy.Subject{Role: app.RoleUnauthorized, Tenant: casb.cfg.Tenant.Default}
+	case principal.IsClient():
+		return policy.Subject{Role: policy.ClientSubject(principal.ClientID), Tenant: principal.Tenant}
+	default:
+		return policy.Subject{Role: principal.Role, Tenant: principal.Tenant}
+	}
+}
+
+// object returns the resource of the request, owned when the principal is the owner
+func (casb *JwtRoleAuth) object(c *gin.Context, principal *Principal) policy.Object {
+	var userID string
+	if principal != nil {
+		userID = principal.UserID
+	}
+	return policy.NewObject(c.Request.URL.Path, c.FullPath(), userID)
+}
+
+// AcceptedAudiences returns the audiences of the tokens accepted on the route.
+// Routes only admins may access accept admin tokens only, routes users may
+// access accept user tokens and, where admins are allowed too, admin tokens so
+// admins need a single login. The user is probed as the owner so owned
+// resources are not taken for admin only ones.
+func (casb *JwtRoleAuth) AcceptedAudiences(tenant string, obj policy.Object, method string) ([]string, error) {
+	shared, owned := obj, obj
+	shared.Owned = false
+	owned.Owned = policy.ResourceOwner(obj.Path) != ""
+
+	admin := policy.Subject{Role: app.RoleAdmin, Tenant: tenant}
+	adminAllowed, err := policy.Enforce(casb.enforcer, admin, shared, method)
+	if err != nil {
+		return nil, err
+	}
+	user := policy.Subject{Role: app.RoleUser, Tenant: tenant}
+	userAllowed, err := policy.Enforce(casb.enforcer, user, owned, method)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case adminAllowed && !userAllowed:
+		return []string{tokens.AudienceAdmin}, nil
+	case adminAllowed:
+		return []string{tokens.AudienceUser, tokens.AudienceAdmin}, nil
+	default:
+		return []string{tokens.AudienceUser}, nil
+	}
+}
+
+func (casb *JwtRoleAuth) GetClaims(c *gin.Context) (jwt.MapClaims, error) {
 	var t string
 	token := c.Request.Header.Get("Authorization")
 	if token == "" {
-		return "unauthorized", http.StatusUnauthorized
+		return nil, errors.New("authorization header is empty")
 	} else if strings.Contains(token, "Bearer") {
 		t = strings.TrimPrefix(token, "Bearer ")
 	} else {
 		t = token
 	}
 
-	claims, err := tokens.ExtractClaim(t, []byte(casb.cfg.Token.SignInKey))
-	if err != nil {
-		return "unauthorized", http.StatusUnauthorized
-	}
-	return cast.ToString(claims["role"]), 0
+	return tokens.ExtractClaim(t, []byte(casb.cfg.Token.SignInKey))
 }
 
+// CheckPermission returns errInvalidToken when the route is not public and the
+// caller could not be authenticated, errInsufficientScope when a client token
+// lacks the scope of the route, false means the caller is authenticated but
+// not allowed
 func (casb *JwtRoleAuth) CheckPermission(c *gin.Context) (bool, error) {
 
 	method := c.Request.Method
-	path := c.Request.URL.Path
 
-	role, status := casb.GetRole(c)
+	principal, status := casb.GetPrincipal(c)
+	subject := casb.subject(principal)
+	obj := casb.object(c, principal)
 
-	if role == "unauthorized" {
-		allowed, err := casb.enforcer.Enforce(role, path, method)
+	if subject.Role == app.RoleUnauthorized {
+		allowed, err := policy.Enforce(casb.enforcer, subject, obj, method)
 		if err != nil {
 			return false, err
 		}
+		if !allowed && status == http.StatusForbidden {
+			return false, errInsufficientScope
+		}
+		if !allowed && status != 0 {
+			return false, errInvalidToken
+		}
 		return allowed, nil
 
 	}
 
-	if status != 0 {
-		return false, errors.New(role)
-	}
-
-	allowed, err := casb.enforcer.Enforce(role, path, method)
+	allowed, err := policy.Enforce(casb.enforcer, subject, obj, method)
 	if err != nil {
 		return false, err
 	}
+	if allowed {
+		setPrincipal(c, principal)
+	}
 
 	return allowed, nil
 }
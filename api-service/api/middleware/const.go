@@ -2,9 +2,18 @@ package middleware
 
 type (
 	ctxKeyRequestAuth int
+	ctxKeyRequestID   int
 )
 
 const (
 	RequestIDHeader                   = "X-Request-Id"
 	RequestAuthCtx  ctxKeyRequestAuth = 0
+	RequestIDCtx    ctxKeyRequestID   = 0
+)
+
+// metadata keys attached to outgoing grpc calls, grpc metadata keys are lowercase
+const (
+	requestIDMetadata = "x-request-id"
+	userIDMetadata    = "x-user-id"
+	userRoleMetadata  = "x-user-role"
 )
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryClientInterceptor attaches the request id and the caller of the request
+// to outgoing calls and bounds calls without a deadline by timeout
+func UnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := withDeadline(ctx, timeout)
+		defer cancel()
+
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the stream counterpart of UnaryClientInterceptor,
+// the deadline is released once the stream is finished
+func StreamClientInterceptor(timeout time.Duration) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, cancel := withDeadline(ctx, timeout)
+
+		stream, err := streamer(outgoingContext(ctx), desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &deadlineStream{ClientStream: stream, cancel: cancel}, nil
+	}
+}
+
+// withDeadline keeps a deadline set by the caller
+func withDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	pairs := make([]string, 0, 6)
+	if id := RequestIDFromContext(ctx); id != "" {
+		pairs = append(pairs, requestIDMetadata, id)
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		if p.UserID != "" {
+			pairs = append(pairs, userIDMetadata, p.UserID)
+		}
+		if p.Role != "" {
+			pairs = append(pairs, userRoleMetadata, p.Role)
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+type deadlineStream struct {
+	grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (s *deadlineStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.cancel()
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+// principalKey is the gin context key of the principal, gin only looks up string keys
+const principalKey = "principal"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// UserID is empty for service clients
+	UserID string
+	// ClientID is the oauth client the token was issued to, if any
+	ClientID  string
+	Role      string
+	SessionID string
+	Scopes    []string
+	Tenant    string
+	ClientIP  string
+	AuthTime  time.Time
+	Acr       string
+}
+
+// IsClient reports whether the caller is a service client rather than a user
+func (p *Principal) IsClient() bool {
+	return p.UserID == "" && p.ClientID != ""
+}
+
+// NewPrincipal builds the principal from the claims of a verified access token
+func NewPrincipal(claims jwt.MapClaims, defaultTenant, clientIP string) *Principal {
+	p := &Principal{
+		ClientID:  cast.ToString(claims["client_id"]),
+		Role:      cast.ToString(claims["role"]),
+		SessionID: cast.ToString(claims["sid"]),
+		Scopes:    tokens.Scopes(claims),
+		Tenant:    tokens.Tenant(claims, defaultTenant),
+		ClientIP:  clientIP,
+		AuthTime:  time.Unix(cast.ToInt64(claims["auth_time"]), 0).UTC(),
+		Acr:       cast.ToString(claims["acr"]),
+	}
+	if !tokens.IsClientToken(claims) {
+		p.UserID = cast.ToString(claims["sub"])
+	}
+	return p
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, RequestAuthCtx, p)
+}
+
+// setPrincipal stores the principal in the gin context and in the request context,
+// so it is found both from handlers and from anything the request context is passed to
+func setPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+}
+
+// PrincipalFromContext returns the caller of the request, ctx may be a *gin.Context
+// or a context derived from the request context
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if v, exists := c.Get(principalKey); exists {
+			p, ok := v.(*Principal)
+			return p, ok
+		}
+		if c.Request == nil {
+			return nil, false
+		}
+		ctx = c.Request.Context()
+	}
+
+	p, ok := ctx.Value(RequestAuthCtx).(*Principal)
+	return p, ok && p != nil
+}
+
+// UserID returns the id of the authenticated user, empty for anonymous callers and clients
+func UserID(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.UserID
+	}
+	return ""
+}
+
+// Role returns the role of the authenticated caller, empty for anonymous callers
+func Role(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Role
+	}
+	return ""
+}
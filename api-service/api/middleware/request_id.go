@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds the ids of callers, they end up in every log line and upstream call
+const maxRequestIDLength = 128
+
+// RequestID keeps a valid X-Request-Id of the caller or generates one, the id
+// is echoed in the response and stored in the request context
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), RequestIDCtx, id))
+		c.Next()
+	}
+}
+
+// validRequestID allows letters, digits and -_.: so an id can not forge log
+// fields or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDFromContext returns the request id, ctx may be a *gin.Context or a
+// context derived from the request context
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		if c.Request == nil {
+			return ""
+		}
+		ctx = c.Request.Context()
+	}
+
+	id, _ := ctx.Value(RequestIDCtx).(string)
+	return id
+}
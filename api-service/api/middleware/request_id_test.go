@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestIDFromContext(c))
+	})
+
+	tests := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{name: "uuid", id: "6f1c2a8e-3b4d-4c5e-9f60-718293a4b5c6", keep: true},
+		{name: "trace like", id: "svc.gateway:42_a", keep: true},
+		{name: "empty", id: ""},
+		{name: "too long", id: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "log injection", id: "abc\" level=error msg=\"forged"},
+		{name: "non ascii", id: "идентификатор"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.id)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got != rec.Body.String() {
+				t.Fatalf("response id %q differs from the context id %q", got, rec.Body.String())
+			}
+			if tt.keep && got != tt.id {
+				t.Errorf("id = %q, want the caller's %q", got, tt.id)
+			}
+			if !tt.keep && (got == tt.id || !validRequestID(got)) {
+				t.Errorf("id = %q, want a generated one", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+
+	"medods/api-service/internal/pkg/config"
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+// StepUpPolicyType is the casbin policy type holding step-up rules:
+// p2, <obj>, <act>, <max_age>, <acr>
+const StepUpPolicyType = "p2"
+
+type stepUpRule struct {
+	maxAge time.Duration
+	acr    string
+}
+
+// CheckStepUp rejects requests to routes that need a recent or stronger
+// authentication than the one the access token was issued for
+func CheckStepUp(enforcer *casbin.CachedEnforcer, cfg config.Config) gin.HandlerFunc {
+	casbinHandler := &JwtRoleAuth{
+		cfg:      cfg,
+		enforcer: enforcer,
+	}
+
+	return func(c *gin.Context) {
+		rule, ok, err := casbinHandler.stepUpRule(c.Request.URL.Path, c.Request.Method)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, "Failed to load step-up policy")
+			return
+		}
+		if !ok {
+			return
+		}
+
+		principal, ok := PrincipalFromContext(c)
+		if !ok {
+			// requests without a valid token are rejected by CheckCasbinPermission
+			return
+		}
+
+		if !tokens.ACRSatisfies(principal.Acr, rule.acr) {
+			c.Header("WWW-Authenticate", fmt.Sprintf(
+				`Bearer error="insufficient_user_authentication", error_description="A stronger authentication is required", acr_values="%s"`,
+				rule.acr,
+			))
+			abortWithError(c, http.StatusUnauthorized, "Stronger authentication required")
+			return
+		}
+
+		if rule.maxAge > 0 && time.Since(principal.AuthTime) > rule.maxAge {
+			c.Header("WWW-Authenticate", fmt.Sprintf(
+				`Bearer error="insufficient_user_authentication", error_description="More recent authentication is required", max_age="%d"`,
+				int(rule.maxAge.Seconds()),
+			))
+			abortWithError(c, http.StatusUnauthorized, "Re-authentication required")
+			return
+		}
+	}
+}
+
+// stepUpRule merges every step-up rule matching the request into the strictest one
+func (casb *JwtRoleAuth) stepUpRule(path, method string) (stepUpRule, bool, error) {
+	var (
+		res     stepUpRule
+		matched bool
+	)
+
+	rules, err := casb.enforcer.GetNamedPolicy(StepUpPolicyType)
+	if err != nil {
+		return res, false, err
+	}
+
+	for _, p := range rules {
+		if len(p) < 4 || p[1] != method {
+			continue
+		}
+		if !util.KeyMatch3(path, p[0]) {
+			continue
+		}
+
+		maxAge, err := time.ParseDuration(p[2])
+		if err != nil {
+			continue
+		}
+
+		if maxAge > 0 && (res.maxAge == 0 || maxAge < res.maxAge) {
+			res.maxAge = maxAge
+		}
+		if !tokens.ACRSatisfies(res.acr, p[3]) {
+			res.acr = p[3]
+		}
+		matched = true
+	}
+
+	return res, matched, nil
+}
@@ -0,0 +1,7 @@
+package models
+
+type AdminLoginReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Tenant   string `json:"tenant"`
+}
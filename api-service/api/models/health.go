@@ -0,0 +1,13 @@
+package models
+
+import "medods/api-service/internal/pkg/health"
+
+type HealthResp struct {
+	Status string `json:"status"`
+}
+
+// ReadyResp reports every dependency, Status is down when any of them is
+type ReadyResp struct {
+	Status       string                   `json:"status"`
+	Dependencies map[string]health.Status `json:"dependencies"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type MessageResp struct {
+	Message string `json:"message"`
+}
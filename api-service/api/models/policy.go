@@ -0,0 +1,39 @@
+package models
+
+// PolicyRule is a permission rule, Tenant is set by the server to the tenant of the caller
+type PolicyRule struct {
+	Subject string `json:"subject" binding:"required"`
+	Tenant  string `json:"tenant"`
+	Object  string `json:"object" binding:"required"`
+	Action  string `json:"action" binding:"required"`
+}
+
+type PolicyRules struct {
+	Rules []*PolicyRule `json:"rules"`
+}
+
+// RoleRule is a role grant, Tenant is set by the server to the tenant of the caller
+type RoleRule struct {
+	Subject string `json:"subject" binding:"required"`
+	Role    string `json:"role" binding:"required"`
+	Tenant  string `json:"tenant"`
+}
+
+type RoleRules struct {
+	Rules []*RoleRule `json:"rules"`
+}
+
+type SubjectRoles struct {
+	Subject string   `json:"subject"`
+	Tenant  string   `json:"tenant"`
+	Roles   []string `json:"roles"`
+}
+
+type PolicyCheckResp struct {
+	Subject   string `json:"subject"`
+	SubjectID string `json:"subject_id,omitempty"`
+	Tenant    string `json:"tenant"`
+	Object    string `json:"object"`
+	Action    string `json:"action"`
+	Allowed   bool   `json:"allowed"`
+}
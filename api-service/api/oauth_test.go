@@ -0,0 +1,501 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"medods/api-service/api/models"
+	"medods/api-service/internal/entity"
+	errorspkg "medods/api-service/internal/errors"
+	"medods/api-service/internal/pkg/config"
+	"medods/api-service/internal/pkg/health"
+	"medods/api-service/internal/pkg/notify"
+	"medods/api-service/internal/pkg/policy"
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/usecase/authorization_code"
+	"medods/api-service/internal/usecase/client"
+	"medods/api-service/internal/usecase/consent"
+)
+
+const (
+	testClientID    = "spa"
+	testRedirectURI = "https://spa.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	testUserPassword = "user_password"
+	// bcrypt hash of testUserPassword
+	testUserPasswordHash = "$2a$04$Gaw1XUevjVuuCcLQVBhEReSM9/XoGgDnX3kYz49NZfA/l9jRWJy9O"
+
+	testServiceClientID     = "batch"
+	testServiceClientSecret = "batch_secret"
+	testTenantClientID      = "acme-batch"
+)
+
+type clientRepo struct {
+	clients map[string]*entity.Client
+}
+
+func (r *clientRepo) Get(ctx context.Context, id string) (*entity.Client, error) {
+	if m, ok := r.clients[id]; ok {
+		return m, nil
+	}
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (r *clientRepo) Create(ctx context.Context, m *entity.Client) error {
+	r.clients[m.ID] = m
+	return nil
+}
+
+type consentRepo struct {
+	mu       sync.Mutex
+	consents map[string]*entity.Consent
+}
+
+func (r *consentRepo) Get(ctx context.Context, userID, clientID string) (*entity.Consent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.consents[userID+"|"+clientID]; ok {
+		return m, nil
+	}
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (r *consentRepo) Upsert(ctx context.Context, m *entity.Consent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consents[m.UserID+"|"+m.ClientID] = m
+	return nil
+}
+
+type authorizationCodeRepo struct {
+	mu    sync.Mutex
+	codes map[string]*entity.AuthorizationCode
+}
+
+func (r *authorizationCodeRepo) Create(ctx context.Context, m *entity.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[m.CodeHash] = m
+	return nil
+}
+
+func (r *authorizationCodeRepo) MarkUsed(ctx context.Context, codeHash string, usedAt time.Time) (*entity.AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.codes[codeHash]
+	if !ok || m.UsedAt != nil || !m.ExpiresAt.After(usedAt) {
+		return nil, errorspkg.ErrorNotFound
+	}
+	m.UsedAt = &usedAt
+	return m, nil
+}
+
+type userStore struct {
+	mu    sync.Mutex
+	users map[string]*entity.User
+}
+
+func (s *userStore) Get(ctx context.Context, filter *entity.UserFilter) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.users {
+		if filter.ID != "" && m.ID == filter.ID || filter.Email != "" && m.Email == filter.Email {
+			return m, nil
+		}
+	}
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (s *userStore) Create(ctx context.Context, m *entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[m.ID] = m
+	return nil
+}
+
+func (s *userStore) Update(ctx context.Context, m *entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.RefreshToken != "" {
+		s.users[m.ID].RefreshToken = m.RefreshToken
+	}
+	return nil
+}
+
+func (s *userStore) Delete(ctx context.Context, filter *entity.UserFilter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, filter.ID)
+	return nil
+}
+
+type oauthTest struct {
+	t      *testing.T
+	cfg    *config.Config
+	server *httptest.Server
+	client *http.Client
+}
+
+// newOAuthTest serves the router with the policies of auth.csv and in-memory repositories
+func newOAuthTest(t *testing.T) *oauthTest {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{APP: "api-service-test"}
+	cfg.Token.SignInKey = "test_signin_key"
+	cfg.Token.AccessTTL = time.Hour
+	cfg.Token.RefreshTTL = 2 * time.Hour
+	cfg.Token.AuthCodeTTL = time.Minute
+	cfg.Token.Issuer = "http://api-service.test"
+	cfg.Tenant.Default = "default"
+
+	m, err := policy.NewModel("")
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	enforcer, err := casbin.NewCachedEnforcer(m, fileadapter.NewAdapter("../auth.csv"))
+	if err != nil {
+		t.Fatalf("NewCachedEnforcer: %v", err)
+	}
+	enforcer.AddNamedDomainMatchingFunc("g", "keyMatch", util.KeyMatch)
+
+	oidcKey, err := tokens.LoadOIDCKey("")
+	if err != nil {
+		t.Fatalf("LoadOIDCKey: %v", err)
+	}
+
+	clients := client.NewClientService(time.Second, &clientRepo{clients: map[string]*entity.Client{
+		testClientID: {
+			ID:           testClientID,
+			Name:         "Single page app",
+			Scopes:       []string{"openid", "profile"},
+			RedirectURIs: []string{testRedirectURI},
+		},
+	}})
+	err = clients.Create(context.Background(), &entity.Client{
+		ID:     testServiceClientID,
+		Name:   "Batch jobs",
+		Scopes: []string{tokens.AudienceUser, "reports"},
+	}, testServiceClientSecret)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	err = clients.Create(context.Background(), &entity.Client{
+		ID:     testTenantClientID,
+		Name:   "Acme batch jobs",
+		Scopes: []string{tokens.AudienceUser},
+		Tenant: "acme",
+	}, testServiceClientSecret)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	logger := zap.NewNop()
+	handler := NewRoute(RouteOption{
+		Config:         cfg,
+		Logger:         logger,
+		ContextTimeout: time.Second,
+		Enforcer:       enforcer,
+		UserStore: &userStore{users: map[string]*entity.User{
+			"user-1": {ID: "user-1", Email: "user@example.com", Password: testUserPasswordHash, Role: "user"},
+		}},
+		Client:            clients,
+		AuthorizationCode: authorization_code.NewAuthorizationCodeService(time.Second, &authorizationCodeRepo{codes: map[string]*entity.AuthorizationCode{}}),
+		Consent:           consent.NewConsentService(time.Second, &consentRepo{consents: map[string]*entity.Consent{}}),
+		OIDCKey:           oidcKey,
+		Health:            health.New(time.Second),
+		Notifier:          notify.New(logger),
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &oauthTest{
+		t:      t,
+		cfg:    cfg,
+		server: server,
+		client: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// userToken signs the access token a password login of the user returns
+func (o *oauthTest) userToken() string {
+	return o.token("user", tokens.AudienceUser, tokens.ACRPassword, time.Now())
+}
+
+// token signs an access token of user-1 with the role for the api, authenticated with acr at authTime
+func (o *oauthTest) token(role, aud, acr string, authTime time.Time) string {
+	jwtHandler := tokens.JwtHandler{
+		Sub:       "user-1",
+		Role:      role,
+		Tenant:    o.cfg.Tenant.Default,
+		Aud:       []string{aud},
+		AuthTime:  authTime.Unix(),
+		Acr:       acr,
+		SigninKey: o.cfg.Token.SignInKey,
+		Log:       zap.NewNop(),
+		Timeout:   int(o.cfg.Token.AccessTTL),
+	}
+	access, _, err := jwtHandler.GenerateJwt()
+	if err != nil {
+		o.t.Fatalf("GenerateJwt: %v", err)
+	}
+	return access
+}
+
+func (o *oauthTest) do(req *http.Request) *http.Response {
+	o.t.Helper()
+	res, err := o.client.Do(req)
+	if err != nil {
+		o.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	o.t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func authorizeParams() url.Values {
+	sum := sha256.Sum256([]byte(testVerifier))
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {testClientID},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {"openid profile"},
+		"state":                 {"state-1"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {authorization_code.CodeChallengeMethodS256},
+		"nonce":                 {"nonce-1"},
+	}
+}
+
+// authorize returns the code of a redirect to the client, the user consents first when asked to
+func (o *oauthTest) authorize(token string) string {
+	o.t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, o.server.URL+"/v1/oauth/authorize?"+authorizeParams().Encode(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res := o.do(req)
+
+	if res.StatusCode == http.StatusOK {
+		var consentResp models.ConsentResp
+		if err := json.NewDecoder(res.Body).Decode(&consentResp); err != nil {
+			o.t.Fatalf("decode consent: %v", err)
+		}
+		if consentResp.ClientID != testClientID {
+			o.t.Fatalf("consent asked for client %q, want %q", consentResp.ClientID, testClientID)
+		}
+
+		form := authorizeParams()
+		form.Set("consent", "approve")
+		req, _ = http.NewRequest(http.MethodPost, o.server.URL+"/v1/oauth/authorize", strings.NewReader(form.Encode()))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res = o.do(req)
+	}
+
+	if res.StatusCode != http.StatusFound {
+		o.t.Fatalf("authorize status = %d, want %d", res.StatusCode, http.StatusFound)
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		o.t.Fatalf("parse redirect: %v", err)
+	}
+	if location.Query().Get("state") != "state-1" {
+		o.t.Errorf("redirect state = %q, want state-1", location.Query().Get("state"))
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		o.t.Fatalf("redirect %s carries no code", location)
+	}
+	return code
+}
+
+// exchange posts the code to the token endpoint and returns the response status with its decoded body
+func (o *oauthTest) exchange(code, verifier string) (int, map[string]interface{}) {
+	o.t.Helper()
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {testClientID},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {verifier},
+	}
+	req, _ := http.NewRequest(http.MethodPost, o.server.URL+"/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res := o.do(req)
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		o.t.Fatalf("decode token response: %v", err)
+	}
+	return res.StatusCode, body
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	o := newOAuthTest(t)
+	token := o.userToken()
+
+	// the first authorization asks for consent, the second one is redirected straight away
+	code := o.authorize(token)
+	status, body := o.exchange(code, testVerifier)
+	if status != http.StatusOK {
+		t.Fatalf("exchange status = %d (%v), want %d", status, body, http.StatusOK)
+	}
+	for _, field := range []string{"access_token", "refresh_token", "id_token"} {
+		if body[field] == "" || body[field] == nil {
+			t.Errorf("token response has no %s: %v", field, body)
+		}
+	}
+	if body["scope"] != "openid profile" {
+		t.Errorf("token scope = %v, want openid profile", body["scope"])
+	}
+
+	claims, err := tokens.ExtractClaim(body["access_token"].(string), []byte(o.cfg.Token.SignInKey))
+	if err != nil {
+		t.Fatalf("ExtractClaim: %v", err)
+	}
+	if claims["sub"] != "user-1" || claims["client_id"] != testClientID {
+		t.Errorf("access token sub = %v, client_id = %v, want user-1 and %s", claims["sub"], claims["client_id"], testClientID)
+	}
+
+	// the consumed code can not be replayed
+	status, body = o.exchange(code, testVerifier)
+	if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("replayed code: status = %d, error = %v, want %d invalid_grant", status, body["error"], http.StatusBadRequest)
+	}
+
+	code = o.authorize(token)
+	status, body = o.exchange(code, "wrong-verifier-wrong-verifier-wrong-verifier")
+	if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("wrong verifier: status = %d, error = %v, want %d invalid_grant", status, body["error"], http.StatusBadRequest)
+	}
+
+	// a code tried with a wrong verifier is consumed as well
+	status, body = o.exchange(code, testVerifier)
+	if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("code after a wrong verifier: status = %d, error = %v, want %d invalid_grant", status, body["error"], http.StatusBadRequest)
+	}
+}
+
+func TestAuthorizeRequiresUser(t *testing.T) {
+	o := newOAuthTest(t)
+
+	req, _ := http.NewRequest(http.MethodGet, o.server.URL+"/v1/oauth/authorize?"+authorizeParams().Encode(), nil)
+	res := o.do(req)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("authorize without a token: status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+// clientCredentials asks for a token of the client and returns the response status with its decoded body
+func (o *oauthTest) clientCredentials(clientID, scope string) (int, map[string]interface{}) {
+	o.t.Helper()
+
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {scope}}
+	req, _ := http.NewRequest(http.MethodPost, o.server.URL+"/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, testServiceClientSecret)
+	res := o.do(req)
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		o.t.Fatalf("decode token response: %v", err)
+	}
+	return res.StatusCode, body
+}
+
+func TestClientCredentialsAudience(t *testing.T) {
+	o := newOAuthTest(t)
+
+	grant := func(scope string) (int, map[string]interface{}) {
+		return o.clientCredentials(testServiceClientID, scope)
+	}
+
+	// a token without the scope of an api would not be accepted anywhere
+	status, body := grant("reports")
+	if status != http.StatusBadRequest || body["error"] != "invalid_scope" {
+		t.Errorf("grant without an api scope: status = %d, error = %v, want %d invalid_scope", status, body["error"], http.StatusBadRequest)
+	}
+
+	status, body = grant(tokens.AudienceUser)
+	if status != http.StatusOK {
+		t.Fatalf("grant status = %d (%v), want %d", status, body, http.StatusOK)
+	}
+	claims, err := tokens.ExtractClaim(body["access_token"].(string), []byte(o.cfg.Token.SignInKey))
+	if err != nil {
+		t.Fatalf("ExtractClaim: %v", err)
+	}
+	if !tokens.HasAudience(claims, tokens.AudienceUser) || tokens.HasAudience(claims, tokens.AudienceAdmin) {
+		t.Errorf("client token aud = %v, want only %s", claims["aud"], tokens.AudienceUser)
+	}
+
+	// admin only routes need an admin-api token
+	req, _ := http.NewRequest(http.MethodGet, o.server.URL+"/v1/policies", nil)
+	req.Header.Set("Authorization", "Bearer "+body["access_token"].(string))
+	res := o.do(req)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("admin route with a user-api client token: status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestResetTokenIsNotAccepted(t *testing.T) {
+	o := newOAuthTest(t)
+
+	jwtHandler := tokens.JwtHandler{Sub: "user-1", SigninKey: o.cfg.Token.SignInKey, Log: zap.NewNop()}
+	reset, err := jwtHandler.GenerateResetJwt("reset-1", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateResetJwt: %v", err)
+	}
+	if _, err := tokens.ExtractClaim(reset, []byte(o.cfg.Token.SignInKey)); err == nil {
+		t.Error("reset token verifies with the signin key")
+	}
+	if _, err := tokens.ExtractResetClaim(o.userToken(), o.cfg.Token.SignInKey); err == nil {
+		t.Error("access token is accepted as a reset token")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, o.server.URL+"/v1/oauth/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+reset)
+	if res := o.do(req); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("userinfo with a reset token: status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestClientCredentialsTenant(t *testing.T) {
+	o := newOAuthTest(t)
+
+	for _, tt := range []struct{ clientID, tenant string }{
+		{clientID: testServiceClientID, tenant: o.cfg.Tenant.Default},
+		{clientID: testTenantClientID, tenant: "acme"},
+	} {
+		status, body := o.clientCredentials(tt.clientID, tokens.AudienceUser)
+		if status != http.StatusOK {
+			t.Fatalf("%s: grant status = %d (%v), want %d", tt.clientID, status, body, http.StatusOK)
+		}
+		claims, err := tokens.ExtractClaim(body["access_token"].(string), []byte(o.cfg.Token.SignInKey))
+		if err != nil {
+			t.Fatalf("ExtractClaim: %v", err)
+		}
+		if got := tokens.Tenant(claims, ""); got != tt.tenant {
+			t.Errorf("%s: token tenant = %q, want %q", tt.clientID, got, tt.tenant)
+		}
+	}
+}
@@ -12,25 +12,42 @@ import (
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"go.uber.org/zap"
 
 	grpcClients "medods/api-service/internal/infrastructure/grpc_service_client"
 	"medods/api-service/internal/pkg/config"
+	"medods/api-service/internal/pkg/health"
+	"medods/api-service/internal/pkg/notify"
 	tokens "medods/api-service/internal/pkg/token"
 	"medods/api-service/internal/usecase/app_version"
+	"medods/api-service/internal/usecase/authorization_code"
+	"medods/api-service/internal/usecase/client"
+	"medods/api-service/internal/usecase/consent"
+	"medods/api-service/internal/usecase/password_reset"
+	"medods/api-service/internal/usecase/user"
 )
 
 type RouteOption struct {
-	Config         *config.Config
-	Logger         *zap.Logger
-	ContextTimeout time.Duration
-	Service        grpcClients.ServiceClient
-	JwtHandler     tokens.JwtHandler
-	AppVersion     app_version.AppVersion
-	Enforcer       *casbin.Enforcer
+	Config            *config.Config
+	Logger            *zap.Logger
+	ContextTimeout    time.Duration
+	Service           grpcClients.ServiceClient
+	UserStore         user.UserStore
+	JwtHandler        tokens.JwtHandler
+	AppVersion        app_version.AppVersion
+	PasswordReset     password_reset.PasswordReset
+	Client            client.Client
+	AuthorizationCode authorization_code.AuthorizationCode
+	Consent           consent.Consent
+	OIDCKey           *tokens.OIDCKey
+	Enforcer          *casbin.CachedEnforcer
+	Health            *health.Health
+	Notifier          *notify.Notifier
 }
 
 // NewRouter
@@ -41,17 +58,28 @@ type RouteOption struct {
 // @name Authorization
 func NewRoute(option RouteOption) *gin.Engine {
 	router := gin.New()
-	router.Use(gin.Logger())
+	router.Use(middleware.RequestID())
+	router.Use(otelgin.Middleware(option.Config.APP))
+	router.Use(middleware.AccessLog(option.Logger))
+	router.Use(middleware.Metrics())
 	router.Use(gin.Recovery())
 
 	HandlerV1 := v1.New(&v1.HandlerV1Config{
-		Config:         option.Config,
-		Logger:         option.Logger,
-		ContextTimeout: option.ContextTimeout,
-		Service:        option.Service,
-		JwtHandler:     option.JwtHandler,
-		AppVersion:     option.AppVersion,
-		Enforcer:       option.Enforcer,
+		Config:            option.Config,
+		Logger:            option.Logger,
+		ContextTimeout:    option.ContextTimeout,
+		Service:           option.Service,
+		UserStore:         option.UserStore,
+		JwtHandler:        option.JwtHandler,
+		AppVersion:        option.AppVersion,
+		PasswordReset:     option.PasswordReset,
+		Client:            option.Client,
+		AuthorizationCode: option.AuthorizationCode,
+		Consent:           option.Consent,
+		OIDCKey:           option.OIDCKey,
+		Enforcer:          option.Enforcer,
+		Health:            option.Health,
+		Notifier:          option.Notifier,
 	})
 
 	corsConfig := cors.DefaultConfig()
@@ -62,14 +90,41 @@ func NewRoute(option RouteOption) *gin.Engine {
 	corsConfig.AllowMethods = []string{"*"}
 	router.Use(cors.New(corsConfig))
 
-	// router.Use(middleware.Tracing)
 	router.Use(middleware.CheckCasbinPermission(option.Enforcer, *option.Config))
+	router.Use(middleware.CheckStepUp(option.Enforcer, *option.Config))
 	router.Static("/media", "./media")
+	router.GET("/.well-known/openid-configuration", HandlerV1.OpenIDConfiguration)
+	router.GET("/healthz", HandlerV1.Healthz)
+	router.GET("/readyz", HandlerV1.Readyz)
+	// scraped with a client token, the monitoring role is granted to the client through the policy api
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	api := router.Group("/v1")
 
 	// AUTH METHODS
 	api.POST("/users/login", HandlerV1.Token)
 	api.GET("/token/:refresh", HandlerV1.UpdateToken)
+	api.POST("/admins/login", HandlerV1.AdminLogin)
+
+	// PASSWORD METHODS
+	api.GET("/users/set/:email", HandlerV1.RequestPasswordReset)
+	api.PUT("/users/password", HandlerV1.ResetPassword)
+
+	// OAUTH METHODS
+	api.GET("/oauth/authorize", HandlerV1.Authorize)
+	api.POST("/oauth/authorize", HandlerV1.AuthorizeConsent)
+	api.POST("/oauth/token", HandlerV1.OAuthToken)
+	api.GET("/oauth/userinfo", HandlerV1.UserInfo)
+	api.GET("/oauth/jwks", HandlerV1.JWKS)
+
+	// POLICY METHODS
+	api.GET("/policies", HandlerV1.ListPolicies)
+	api.POST("/policies", HandlerV1.AddPolicy)
+	api.DELETE("/policies", HandlerV1.RemovePolicy)
+	api.GET("/policies/check", HandlerV1.CheckPolicy)
+	api.GET("/policies/roles", HandlerV1.ListRoleRules)
+	api.POST("/policies/roles", HandlerV1.AddRoleRule)
+	api.DELETE("/policies/roles", HandlerV1.RemoveRoleRule)
+	api.GET("/policies/roles/:subject", HandlerV1.SubjectRoles)
 
 	url := ginSwagger.URL("swagger/doc.json")
 	api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
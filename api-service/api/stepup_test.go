@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"medods/api-service/api/models"
+	tokens "medods/api-service/internal/pkg/token"
+)
+
+func TestStepUpRequiresRecentLogin(t *testing.T) {
+	o := newOAuthTest(t)
+
+	// auth.csv asks for a login within the last 5 minutes before policies change
+	token := o.token("admin", tokens.AudienceAdmin, tokens.ACRPassword, time.Now().Add(-10*time.Minute))
+	req, _ := http.NewRequest(http.MethodPost, o.server.URL+"/v1/policies", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	res := o.do(req)
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+	if challenge := res.Header.Get("WWW-Authenticate"); !strings.Contains(challenge, `error="insufficient_user_authentication"`) || !strings.Contains(challenge, `max_age="300"`) {
+		t.Errorf("WWW-Authenticate = %q, want an insufficient_user_authentication challenge with max_age", challenge)
+	}
+
+	var body models.StandartError
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if body.Error.Message == "" {
+		t.Errorf("error response has no message")
+	}
+}
+
+func TestStepUpRequiresMFA(t *testing.T) {
+	o := newOAuthTest(t)
+
+	listDeleted := func(acr string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, o.server.URL+"/v1/users/list/deleted", nil)
+		req.Header.Set("Authorization", "Bearer "+o.token("admin", tokens.AudienceAdmin, acr, time.Now()))
+		return o.do(req)
+	}
+
+	// a fresh password login is not enough for the mfa rule of auth.csv
+	res := listDeleted(tokens.ACRPassword)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("password login: status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+	if challenge := res.Header.Get("WWW-Authenticate"); !strings.Contains(challenge, `error="insufficient_user_authentication"`) || !strings.Contains(challenge, `acr_values="mfa"`) {
+		t.Errorf("WWW-Authenticate = %q, want an insufficient_user_authentication challenge for mfa", challenge)
+	}
+
+	if res := listDeleted(tokens.ACRMFA); res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		t.Errorf("mfa login: status = %d, want the request to pass the step-up check", res.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/pkg/tracing/tracingtest"
+)
+
+func TestRequestSpans(t *testing.T) {
+	// the router picks the provider up when it is built
+	exporter := tracingtest.Record(t)
+	o := newOAuthTest(t)
+
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {tokens.AudienceUser}}
+	req, _ := http.NewRequest(http.MethodPost, o.server.URL+"/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(testServiceClientID, testServiceClientSecret)
+	if res := o.do(req); res.StatusCode != http.StatusOK {
+		t.Fatalf("token status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	server, ok := spans["/v1/oauth/token"]
+	if !ok {
+		t.Fatalf("no server span for the route, got %v", spanNames(exporter))
+	}
+	// the client secret is compared and the token signed within the request
+	for _, name := range []string{"bcrypt.compare", "jwt.sign"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span, got %v", name, spanNames(exporter))
+			continue
+		}
+		if span.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("%s span is not in the trace of the request", name)
+		}
+	}
+}
+
+func spanNames(exporter *tracetest.InMemoryExporter) []string {
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	return names
+}
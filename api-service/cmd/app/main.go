@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -13,11 +16,24 @@ import (
 )
 
 func main() {
-	// config
-	config, err := configpkg.NewConfig()
+	// config, layered from the defaults, the config file, env vars and flags
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print the config with secrets redacted and exit")
+	config, err := configpkg.Load(fs, os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *printConfig {
+		out, err := config.Redacted()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(out)
+		return
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
 
 	// app
 	app, err := app.NewApp(*config)
@@ -25,19 +41,15 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// run application
+	// the context is canceled on the first signal, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		app.Logger.Info("Listen: ", zap.String("address", config.Server.Host+config.Server.Port))
-		if err := app.Run(); err != nil {
-			app.Logger.Error("app run", zap.Error(err))
-		}
+		<-ctx.Done()
+		stop()
 	}()
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	<-sigs
-
-	// app stops
-	app.Logger.Info("api gateway service stops")
-	app.Stop()
+	// run application, it returns once it is stopped
+	if err := app.Run(ctx); err != nil {
+		app.Logger.Fatal("app run", zap.Error(err))
+	}
 }
@@ -2,125 +2,310 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"medods/api-service/api"
+	"medods/api-service/api/middleware"
 	grpcService "medods/api-service/internal/infrastructure/grpc_service_client"
 	"medods/api-service/internal/infrastructure/repository/postgresql"
 	"medods/api-service/internal/pkg/config"
+	"medods/api-service/internal/pkg/health"
 	"medods/api-service/internal/pkg/logger"
+	"medods/api-service/internal/pkg/metrics"
+	"medods/api-service/internal/pkg/notify"
+	"medods/api-service/internal/pkg/policy"
 	"medods/api-service/internal/pkg/postgres"
+	tokens "medods/api-service/internal/pkg/token"
+	"medods/api-service/internal/pkg/tracing"
 	"medods/api-service/internal/usecase/app_version"
+	"medods/api-service/internal/usecase/authorization_code"
+	"medods/api-service/internal/usecase/client"
+	"medods/api-service/internal/usecase/consent"
+	"medods/api-service/internal/usecase/password_reset"
+	"medods/api-service/internal/usecase/user"
 	"net/http"
 	"time"
 
-	"github.com/casbin/casbin/util"
 	"github.com/casbin/casbin/v2"
-	defaultrolemanager "github.com/casbin/casbin/v2/rbac/default-role-manager"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	Config     *config.Config
-	Logger     *zap.Logger
-	DB         *postgres.PostgresDB
-	server     *http.Server
-	Enforcer   *casbin.Enforcer
-	Clients    grpcService.ServiceClient
-	appVersion app_version.AppVersion
+	Config            *config.Config
+	Logger            *zap.Logger
+	DB                *postgres.PostgresDB
+	server            *http.Server
+	Enforcer          *casbin.CachedEnforcer
+	Redis             *redis.Client
+	Clients           grpcService.ServiceClient
+	appVersion        app_version.AppVersion
+	passwordReset     password_reset.PasswordReset
+	client            client.Client
+	authorizationCode authorization_code.AuthorizationCode
+	consent           consent.Consent
+	oidcKey           *tokens.OIDCKey
+	closeEnforcer     func()
+	tracerProvider    *sdktrace.TracerProvider
+	health            *health.Health
+	notifier          *notify.Notifier
 }
 
-func NewApp(cfg config.Config) (*App, error) {
+func NewApp(cfg config.Config) (_ *App, err error) {
 	// logger init
 	logger, err := logger.New(cfg.LogLevel, cfg.Environment, cfg.APP+".log")
 	if err != nil {
 		return nil, err
 	}
 
+	// whatever is opened before a step fails is closed again
+	a := &App{Config: &cfg, Logger: logger}
+	defer func() {
+		if err != nil {
+			a.fail(err)
+		}
+	}()
+
+	// tracer provider init, installed globally before any instrumented client is created
+	a.tracerProvider, err = tracing.NewTracerProvider(context.Background(), &cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// postgres init
 	db, err := postgres.New(&cfg)
 	if err != nil {
 		return nil, err
 	}
+	a.DB = db
+	if err := prometheus.Register(metrics.NewPoolCollector(db.Pool)); err != nil {
+		return nil, err
+	}
+
+	// rules rewritten by migrations get the ids the policy api removes them by
+	if err := policy.SyncRuleIDs(context.Background(), db); err != nil {
+		return nil, err
+	}
 
-	// initialization enforcer
-	enforcer, err := casbin.NewEnforcer("auth.conf", "auth.csv")
+	// initialization enforcer, policies are stored in postgres and synced between replicas through redis
+	a.Redis = policy.NewRedisClient(&cfg)
+	a.Enforcer, a.closeEnforcer, err = policy.NewCachedEnforcer(&cfg, logger, a.Redis)
 	if err != nil {
 		return nil, err
 	}
 
-	var contextTimeout time.Duration
+	contextTimeout := cfg.Context.Timeout
+
+	appVersionRepo := postgresql.NewAppVersionRepo(db)
+
+	a.appVersion = app_version.NewAppVersionService(contextTimeout, appVersionRepo)
+
+	passwordResetRepo := postgresql.NewPasswordResetRepo(db)
+
+	a.passwordReset = password_reset.NewPasswordResetService(contextTimeout, passwordResetRepo)
+
+	clientRepo := postgresql.NewClientRepo(db)
 
-	// context timeout initialization
-	contextTimeout, err = time.ParseDuration(cfg.Context.Timeout)
+	a.client = client.NewClientService(contextTimeout, clientRepo)
+
+	// oidc signing key init
+	a.oidcKey, err = tokens.LoadOIDCKey(cfg.Token.OIDCKeyFile)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Token.OIDCKeyFile == "" {
+		logger.Warn("TOKEN_OIDC_KEY_FILE is not set, id tokens are signed with an ephemeral key")
+	}
 
-	appVersionRepo := postgresql.NewAppVersionRepo(db)
+	authorizationCodeRepo := postgresql.NewAuthorizationCodeRepo(db)
+
+	a.authorizationCode = authorization_code.NewAuthorizationCodeService(contextTimeout, authorizationCodeRepo)
 
-	appVersionUseCase := app_version.NewAppVersionService(contextTimeout, appVersionRepo)
+	consentRepo := postgresql.NewConsentRepo(db)
 
-	return &App{
-		Config:     &cfg,
-		Logger:     logger,
-		DB:         db,
-		Enforcer:   enforcer,
-		appVersion: appVersionUseCase,
-	}, nil
+	a.consent = consent.NewConsentService(contextTimeout, consentRepo)
+
+	return a, nil
 }
 
-func (a *App) Run() error {
-	contextTimeout, err := time.ParseDuration(a.Config.Context.Timeout)
-	if err != nil {
-		return fmt.Errorf("error while parsing context timeout: %v", err)
+// Run serves until ctx is done or the server fails, then shuts the app down in order
+func (a *App) Run(ctx context.Context) error {
+	contextTimeout := a.Config.Context.Timeout
+
+	// user-service is only dialed when users are kept there
+	var userStore user.UserStore
+	switch a.Config.UserStore {
+	case "grpc":
+		// outgoing calls carry the request id and the caller, and are bounded by the context timeout
+		clients, err := grpcService.New(a.Config,
+			grpc.WithChainUnaryInterceptor(middleware.UnaryClientInterceptor(contextTimeout), metrics.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(middleware.StreamClientInterceptor(contextTimeout)),
+		)
+		if err != nil {
+			return a.fail(err)
+		}
+		a.Clients = clients
+		userStore = grpcService.NewUserStore(clients.UserService())
+	case "postgres":
+		userStore = postgresql.NewUserRepo(a.DB)
+	default:
+		return a.fail(fmt.Errorf("unknown user store %q", a.Config.UserStore))
 	}
 
-	clients, err := grpcService.New(a.Config)
-	if err != nil {
-		return err
+	// readiness checks, the user service is only needed when users are kept there
+	a.health = health.New(a.Config.Health.Timeout)
+	a.health.Add("postgres", a.DB.Ping)
+	a.health.Add("redis", func(ctx context.Context) error {
+		return a.Redis.Ping(ctx).Err()
+	})
+	a.health.Add("casbin", func(ctx context.Context) error {
+		rules, err := a.Enforcer.GetPolicy()
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return errors.New("policy is not loaded")
+		}
+		return nil
+	})
+	if a.Config.UserStore == "grpc" {
+		a.health.Add("user_service", a.Clients.Ready)
+	}
+
+	// notifications sent in the background are flushed on shutdown
+	a.notifier = notify.New(a.Logger)
+
+	// the policy is loaded before the server accepts requests
+	if err := a.Enforcer.LoadPolicy(); err != nil {
+		return a.fail(err)
 	}
-	a.Clients = clients
 
 	// api init
 	handler := api.NewRoute(api.RouteOption{
-		Config:         a.Config,
-		Logger:         a.Logger,
-		ContextTimeout: contextTimeout,
-		Enforcer:       a.Enforcer,
-		Service:        clients,
-		AppVersion:     a.appVersion,
+		Config:            a.Config,
+		Logger:            a.Logger,
+		ContextTimeout:    contextTimeout,
+		Enforcer:          a.Enforcer,
+		Service:           a.Clients,
+		UserStore:         userStore,
+		AppVersion:        a.appVersion,
+		PasswordReset:     a.passwordReset,
+		Client:            a.client,
+		AuthorizationCode: a.authorizationCode,
+		Consent:           a.consent,
+		OIDCKey:           a.oidcKey,
+		Health:            a.health,
+		Notifier:          a.notifier,
 	})
-	err = a.Enforcer.LoadPolicy()
-	if err != nil {
-		return err
-	}
-	roleManager := a.Enforcer.GetRoleManager().(*defaultrolemanager.RoleManagerImpl)
-
-	roleManager.AddMatchingFunc("keyMatch", util.KeyMatch)
-	roleManager.AddMatchingFunc("keyMatch3", util.KeyMatch3)
 
 	// server init
-	a.server, err = api.NewServer(a.Config, handler)
-	if err != nil {
-		return fmt.Errorf("error while initializing server: %v", err)
-	}
+	a.server = api.NewServer(a.Config, handler)
 
-	return a.server.ListenAndServe()
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		a.Logger.Info("Listen: ", zap.String("address", a.server.Addr))
+		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("error while serving http: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		<-gctx.Done()
+		return a.Stop()
+	})
+	return g.Wait()
 }
 
-func (a *App) Stop() {
+// Stop fails readiness, waits the drain delay for load balancers to notice,
+// stops accepting requests and drains the in-flight ones and the notifications
+// within the shutdown timeout, the dependencies are closed last so draining
+// requests can still use them
+func (a *App) Stop() error {
+	a.Logger.Info("api gateway service stops")
 
-	// close database
-	a.DB.Close()
+	// fail readiness first so no new traffic is routed here
+	if a.health != nil {
+		a.health.Shutdown()
+		time.Sleep(a.Config.Server.DrainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
+	defer cancel()
 
-	// close grpc connections
-	a.Clients.Close()
+	// stop accepting and drain in-flight requests
+	var errs []error
+	if a.server != nil {
+		if err := a.server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown server http: %w", err))
+		}
+	}
+
+	// flush notifications sent by the drained requests
+	if a.notifier != nil {
+		if err := a.notifier.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush notifications: %w", err))
+		}
+	}
 
-	// shutdown server http
-	if err := a.server.Shutdown(context.Background()); err != nil {
-		a.Logger.Error("shutdown server http ", zap.Error(err))
+	errs = append(errs, a.close(ctx)...)
+	for _, err := range errs {
+		a.Logger.Error("app stop", zap.Error(err))
 	}
 
 	// zap logger sync
 	a.Logger.Sync()
+
+	return errors.Join(errs...)
+}
+
+// fail closes what was opened when the app fails before it serves and returns err
+func (a *App) fail(err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	for _, err := range a.close(ctx) {
+		a.Logger.Error("app close", zap.Error(err))
+	}
+	a.Logger.Sync()
+	return err
+}
+
+// close releases the dependencies opened so far
+func (a *App) close(ctx context.Context) []error {
+	var errs []error
+
+	// close grpc connections, they are only dialed for the grpc user store
+	if a.Config.UserStore == "grpc" && a.Clients != nil {
+		a.Clients.Close()
+	}
+
+	// close the policy watcher and adapter
+	if a.closeEnforcer != nil {
+		a.closeEnforcer()
+	}
+
+	// close redis
+	if a.Redis != nil {
+		if err := a.Redis.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close redis: %w", err))
+		}
+	}
+
+	// close database
+	if a.DB != nil {
+		a.DB.Close()
+	}
+
+	// flush spans
+	if a.tracerProvider != nil {
+		if err := a.tracerProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown tracer provider: %w", err))
+		}
+	}
+
+	return errs
 }
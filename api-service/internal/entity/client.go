@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type Client struct {
+	ID           string
+	Name         string
+	SecretHash   string
+	Scopes       []string
+	RedirectURIs []string
+	Tenant       string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
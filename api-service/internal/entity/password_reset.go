@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+type PasswordReset struct {
+	GUID      string
+	UserID    string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+type User struct {
+	ID           string
+	FullName     string
+	Email        string
+	Password     string
+	DateOfBirth  string
+	ProfileImg   string
+	Card         string
+	Gender       string
+	PhoneNumber  string
+	Role         string
+	RefreshToken string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+}
+
+// UserFilter selects a single user, only one field is expected to be set
+type UserFilter struct {
+	ID    string
+	Email string
+}
@@ -0,0 +1,126 @@
+package grpc_service_clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+
+	"medods/api-service/internal/pkg/config"
+)
+
+const userServiceName = "user.UserService"
+
+// idempotentUserMethods are retried on transient failures, writes are never retried
+var idempotentUserMethods = []string{"Get", "List"}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+// dialOptions balances calls over every address the service name resolves to,
+// retries idempotent calls, keeps idle connections alive and guards the
+// service with a circuit breaker
+func dialOptions(cfg *config.Config, service string, idempotent []string) ([]grpc.DialOption, error) {
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+	}
+	// grpc allows at most 5 attempts, a single attempt disables retries
+	if cfg.GRPC.RetryMaxAttempts > 1 {
+		names := make([]methodName, 0, len(idempotent))
+		for _, method := range idempotent {
+			names = append(names, methodName{Service: service, Method: method})
+		}
+		sc.MethodConfig = append(sc.MethodConfig, methodConfig{
+			Name: names,
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          cfg.GRPC.RetryMaxAttempts,
+				InitialBackoff:       durationString(cfg.GRPC.RetryInitialBackoff),
+				MaxBackoff:           durationString(cfg.GRPC.RetryMaxBackoff),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		})
+	}
+
+	serviceConfigJSON, err := json.Marshal(sc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s service config: %w", service, err)
+	}
+
+	breaker := newBreaker(service, cfg.GRPC.BreakerFailures, cfg.GRPC.BreakerTimeout)
+
+	return []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(string(serviceConfigJSON)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.GRPC.KeepaliveTime,
+			Timeout:             cfg.GRPC.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithChainUnaryInterceptor(breakerUnaryInterceptor(breaker)),
+	}, nil
+}
+
+// durationString formats a duration the way the grpc service config expects, in seconds with an "s" suffix
+func durationString(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// newBreaker opens after failures consecutive failed calls and lets a probe call through after timeout
+func newBreaker(name string, failures int, timeout time.Duration) *gobreaker.CircuitBreaker {
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: 1,
+		Timeout:     timeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return failures > 0 && counts.ConsecutiveFailures >= uint32(failures)
+		},
+		// only failures of the service itself count, a not found user is a successful call
+		IsSuccessful: func(err error) bool {
+			switch status.Code(err) {
+			case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+				return false
+			}
+			return true
+		},
+	})
+}
+
+// breakerUnaryInterceptor fails fast with codes.Unavailable while the breaker is open
+func breakerUnaryInterceptor(breaker *gobreaker.CircuitBreaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, err := breaker.Execute(func() (interface{}, error) {
+			return nil, invoker(ctx, method, req, reply, cc, opts...)
+		})
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return status.Errorf(codes.Unavailable, "%s is unavailable: %v", breaker.Name(), err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,173 @@
+package grpc_service_clients
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pbu "medods/api-service/genproto/user-proto"
+	"medods/api-service/internal/pkg/config"
+)
+
+// fakeUserService fails the first failures calls with codes.Unavailable and counts every call
+type fakeUserService struct {
+	pbu.UnimplementedUserServiceServer
+	failures int32
+	calls    atomic.Int32
+}
+
+func (s *fakeUserService) Get(ctx context.Context, filter *pbu.Filter) (*pbu.GetUserResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "user service is restarting")
+	}
+	return &pbu.GetUserResponse{User: &pbu.User{Id: filter.Id}}, nil
+}
+
+func (s *fakeUserService) Update(ctx context.Context, user *pbu.User) (*pbu.User, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "user service is restarting")
+	}
+	return user, nil
+}
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.GRPC.RetryMaxAttempts = 3
+	cfg.GRPC.RetryInitialBackoff = time.Millisecond
+	cfg.GRPC.RetryMaxBackoff = 10 * time.Millisecond
+	cfg.GRPC.KeepaliveTime = 30 * time.Second
+	cfg.GRPC.KeepaliveTimeout = 10 * time.Second
+	cfg.GRPC.BreakerFailures = 3
+	cfg.GRPC.BreakerTimeout = time.Minute
+	return cfg
+}
+
+// dialFakes serves every fake on its own in-memory listener and dials all of
+// them through one connection with the options of the user service client
+func dialFakes(t *testing.T, cfg *config.Config, fakes ...*fakeUserService) pbu.UserServiceClient {
+	t.Helper()
+
+	listeners := make(map[string]*bufconn.Listener, len(fakes))
+	addresses := make([]resolver.Address, 0, len(fakes))
+	for i, fake := range fakes {
+		addr := fmt.Sprintf("user-service-%d", i)
+		lis := bufconn.Listen(1 << 20)
+		server := grpc.NewServer()
+		pbu.RegisterUserServiceServer(server, fake)
+		go server.Serve(lis)
+		t.Cleanup(server.Stop)
+
+		listeners[addr] = lis
+		addresses = append(addresses, resolver.Address{Addr: addr})
+	}
+
+	r := manual.NewBuilderWithScheme("fake")
+	r.InitialState(resolver.State{Addresses: addresses})
+
+	opts, err := dialOptions(cfg, userServiceName, idempotentUserMethods)
+	if err != nil {
+		t.Fatalf("dialOptions: %v", err)
+	}
+	conn, err := grpc.Dial("fake:///user-service", append(opts,
+		grpc.WithResolvers(r),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return listeners[addr].DialContext(ctx)
+		}),
+	)...)
+	if err != nil {
+		t.Fatalf("grpc.Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pbu.NewUserServiceClient(conn)
+}
+
+func TestRetryUnavailable(t *testing.T) {
+	fake := &fakeUserService{failures: 2}
+	client := dialFakes(t, testConfig(), fake)
+
+	res, err := client.Get(context.Background(), &pbu.Filter{Id: "1"})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if res.User.Id != "1" {
+		t.Errorf("Get returned user %q, want 1", res.User.Id)
+	}
+	if calls := fake.calls.Load(); calls != 3 {
+		t.Errorf("Get reached the service %d times, want 3", calls)
+	}
+}
+
+func TestNoRetryForWrites(t *testing.T) {
+	fake := &fakeUserService{failures: 1}
+	client := dialFakes(t, testConfig(), fake)
+
+	_, err := client.Update(context.Background(), &pbu.User{Id: "1"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Update error = %v, want code Unavailable", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("Update reached the service %d times, want 1", calls)
+	}
+}
+
+func TestBreakerOpens(t *testing.T) {
+	cfg := testConfig()
+	// without retries every failed call counts once towards the breaker
+	cfg.GRPC.RetryMaxAttempts = 1
+	fake := &fakeUserService{failures: 100}
+	client := dialFakes(t, cfg, fake)
+
+	for i := 0; i < cfg.GRPC.BreakerFailures; i++ {
+		if _, err := client.Get(context.Background(), &pbu.Filter{Id: "1"}); status.Code(err) != codes.Unavailable {
+			t.Fatalf("Get %d error = %v, want code Unavailable", i, err)
+		}
+	}
+
+	// the open breaker fails fast with the code handlers answer 503 for
+	_, err := client.Get(context.Background(), &pbu.Filter{Id: "1"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Get error = %v, want code Unavailable", err)
+	}
+	if calls := fake.calls.Load(); calls != int32(cfg.GRPC.BreakerFailures) {
+		t.Errorf("open breaker let calls through, the service was reached %d times, want %d", calls, cfg.GRPC.BreakerFailures)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	fakes := []*fakeUserService{{}, {}, {}}
+	client := dialFakes(t, testConfig(), fakes...)
+
+	// the first calls may only see the backends connected so far
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.Get(context.Background(), &pbu.Filter{Id: "1"}); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+
+		balanced := true
+		for _, fake := range fakes {
+			if fake.calls.Load() == 0 {
+				balanced = false
+			}
+		}
+		if balanced {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("calls were not spread over every backend: %d, %d, %d", fakes[0].calls.Load(), fakes[1].calls.Load(), fakes[2].calls.Load())
+		}
+	}
+}
@@ -1,18 +1,21 @@
 package grpc_service_clients
 
 import (
+	"context"
 	"fmt"
 
 	pbu "medods/api-service/genproto/user-proto"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 
 	"medods/api-service/internal/pkg/config"
 )
 
 type ServiceClient interface {
 	UserService() pbu.UserServiceClient
+	Ready(ctx context.Context) error
 	Close()
 }
 
@@ -21,14 +24,28 @@ type serviceClient struct {
 	userService pbu.UserServiceClient
 }
 
-func New(cfg *config.Config) (ServiceClient, error) {
+// New dials the services, opts are appended to the default dial options so
+// callers can chain their own interceptors after the tracing ones
+func New(cfg *config.Config, opts ...grpc.DialOption) (ServiceClient, error) {
+	credentials, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// user service
+	userServiceOpts, err := dialOptions(cfg, userServiceName, idempotentUserMethods)
+	if err != nil {
+		return nil, err
+	}
 
+	// the dns resolver returns every pod behind the service name, calls are balanced over them
 	connUserService, err := grpc.Dial(
-		fmt.Sprintf("%s%s", cfg.UserService.Host, cfg.UserService.Port),
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
-		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+		fmt.Sprintf("dns:///%s%s", cfg.UserService.Host, cfg.UserService.Port),
+		append(append([]grpc.DialOption{
+			credentials,
+			grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+		}, userServiceOpts...), opts...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -46,6 +63,21 @@ func (s *serviceClient) UserService() pbu.UserServiceClient {
 	return s.userService
 }
 
+// Ready fails unless every connection is connected or idle, idle connections
+// are asked to reconnect so the next check sees their real state
+func (s *serviceClient) Ready(ctx context.Context) error {
+	for _, conn := range s.connections {
+		switch state := conn.GetState(); state {
+		case connectivity.Ready:
+		case connectivity.Idle:
+			conn.Connect()
+		default:
+			return fmt.Errorf("connection to %s is %s", conn.Target(), state)
+		}
+	}
+	return ctx.Err()
+}
+
 func (s *serviceClient) Close() {
 	for _, conn := range s.connections {
 		if err := conn.Close(); err != nil {
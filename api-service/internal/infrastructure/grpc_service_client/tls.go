@@ -0,0 +1,150 @@
+package grpc_service_clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"medods/api-service/internal/pkg/config"
+)
+
+// transportCredentials returns tls credentials, or insecure ones when tls is
+// disabled, config.Validate requires tls in production
+func transportCredentials(cfg *config.Config) (grpc.DialOption, error) {
+	if !cfg.GRPC.TLSEnabled {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+
+	reloader := &certReloader{
+		caFile:   cfg.GRPC.CAFile,
+		certFile: cfg.GRPC.CertFile,
+		keyFile:  cfg.GRPC.KeyFile,
+	}
+	// files are loaded once here so a bad configuration fails at startup
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.GRPC.ServerNameOverride,
+		// the server certificate is verified in VerifyConnection against the reloadable ca pool
+		InsecureSkipVerify: true,
+		VerifyConnection:   reloader.verifyConnection,
+	}
+	if cfg.GRPC.CertFile != "" {
+		tlsConfig.GetClientCertificate = reloader.clientCertificate
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
+
+// certReloader reads the ca bundle and the client key pair again when the
+// files change on disk, so rotated certificates apply to new connections
+// without a restart
+type certReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	modTimes map[string]time.Time
+	pool     *x509.CertPool
+	cert     *tls.Certificate
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("grpc tls: server sent no certificate")
+	}
+	if err := r.reload(); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		// a nil pool verifies against the system roots
+		Roots:         pool,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// reload loads the files whose modification time changed since the last load
+func (r *certReloader) reload() error {
+	modTimes := make(map[string]time.Time, 3)
+	for _, file := range []string{r.caFile, r.certFile, r.keyFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("grpc tls: %w", err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	r.mu.RLock()
+	changed := r.modTimes == nil
+	for file, modTime := range modTimes {
+		if !r.modTimes[file].Equal(modTime) {
+			changed = true
+		}
+	}
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		ca, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("grpc tls: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("grpc tls: no certificates found in %s", r.caFile)
+		}
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("grpc tls: %w", err)
+		}
+		cert = &keyPair
+	}
+
+	r.mu.Lock()
+	r.modTimes, r.pool, r.cert = modTimes, pool, cert
+	r.mu.Unlock()
+	return nil
+}
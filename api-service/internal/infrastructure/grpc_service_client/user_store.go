@@ -0,0 +1,93 @@
+package grpc_service_clients
+
+import (
+	"context"
+
+	pbu "medods/api-service/genproto/user-proto"
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/usecase/user"
+)
+
+type userStore struct {
+	client pbu.UserServiceClient
+}
+
+// NewUserStore keeps the users in user-service
+func NewUserStore(client pbu.UserServiceClient) user.UserStore {
+	return &userStore{
+		client: client,
+	}
+}
+
+func (s *userStore) Get(ctx context.Context, filter *entity.UserFilter) (*entity.User, error) {
+	res, err := s.client.Get(ctx, userFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return userEntity(res.User), nil
+}
+
+func (s *userStore) Create(ctx context.Context, m *entity.User) error {
+	_, err := s.client.Create(ctx, userMessage(m))
+	return err
+}
+
+func (s *userStore) Update(ctx context.Context, m *entity.User) error {
+	_, err := s.client.Update(ctx, userMessage(m))
+	return err
+}
+
+func (s *userStore) Delete(ctx context.Context, filter *entity.UserFilter) error {
+	_, err := s.client.Delete(ctx, userFilter(filter))
+	return err
+}
+
+func userFilter(filter *entity.UserFilter) *pbu.Filter {
+	return &pbu.Filter{
+		Id:    filter.ID,
+		Email: filter.Email,
+	}
+}
+
+func userMessage(m *entity.User) *pbu.User {
+	return &pbu.User{
+		Id:           m.ID,
+		FullName:     m.FullName,
+		Email:        m.Email,
+		Password:     m.Password,
+		DateOfBirth:  m.DateOfBirth,
+		ProfileImg:   m.ProfileImg,
+		Card:         m.Card,
+		Gender:       m.Gender,
+		PhoneNumber:  m.PhoneNumber,
+		Role:         m.Role,
+		RefreshToken: m.RefreshToken,
+	}
+}
+
+func userEntity(m *pbu.User) *entity.User {
+	res := &entity.User{
+		ID:           m.Id,
+		FullName:     m.FullName,
+		Email:        m.Email,
+		Password:     m.Password,
+		DateOfBirth:  m.DateOfBirth,
+		ProfileImg:   m.ProfileImg,
+		Card:         m.Card,
+		Gender:       m.Gender,
+		PhoneNumber:  m.PhoneNumber,
+		Role:         m.Role,
+		RefreshToken: m.RefreshToken,
+	}
+	if m.CreatedAt != nil {
+		res.CreatedAt = m.CreatedAt.AsTime()
+	}
+	if m.UpdatedAt != nil {
+		res.UpdatedAt = m.UpdatedAt.AsTime()
+	}
+	if m.DeletedAt != nil {
+		deletedAt := m.DeletedAt.AsTime()
+		res.DeletedAt = &deletedAt
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/postgres"
+	"medods/api-service/internal/usecase/authorization_code"
+)
+
+type authorizationCodeRepo struct {
+	tableName string
+	db        *postgres.PostgresDB
+}
+
+func NewAuthorizationCodeRepo(db *postgres.PostgresDB) authorization_code.AuthorizationCodeRepo {
+	return &authorizationCodeRepo{
+		tableName: "oauth_authorization_codes",
+		db:        db,
+	}
+}
+
+func (r *authorizationCodeRepo) Create(ctx context.Context, m *entity.AuthorizationCode) error {
+	clauses := map[string]interface{}{
+		"code_hash":             m.CodeHash,
+		"client_id":             m.ClientID,
+		"user_id":               m.UserID,
+		"tenant":                m.Tenant,
+		"redirect_uri":          m.RedirectURI,
+		"scopes":                m.Scopes,
+		"code_challenge":        m.CodeChallenge,
+		"code_challenge_method": m.CodeChallengeMethod,
+		"nonce":                 m.Nonce,
+		"auth_time":             m.AuthTime,
+		"expires_at":            m.ExpiresAt,
+		"created_at":            m.CreatedAt,
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.Insert(r.tableName).SetMap(clauses).ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" create")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
+	}
+	return nil
+}
+
+// MarkUsed sets used_at in a single statement so a code cannot be exchanged twice
+func (r *authorizationCodeRepo) MarkUsed(ctx context.Context, codeHash string, usedAt time.Time) (*entity.AuthorizationCode, error) {
+	sqlStr, args, err := r.db.Sq.Builder.
+		Update(r.tableName).
+		Set("used_at", usedAt).
+		Where(r.db.Sq.And(
+			r.db.Sq.Equal("code_hash", codeHash),
+			r.db.Sq.Equal("used_at", nil),
+			r.db.Sq.Gt("expires_at", usedAt),
+		)).
+		Suffix(`RETURNING code_hash, client_id, user_id, tenant, redirect_uri, scopes,
+			code_challenge, code_challenge_method, nonce, auth_time, expires_at, used_at, created_at`).
+		ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" update")
+	}
+
+	var res entity.AuthorizationCode
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.CodeHash,
+		&res.ClientID,
+		&res.UserID,
+		&res.Tenant,
+		&res.RedirectURI,
+		&res.Scopes,
+		&res.CodeChallenge,
+		&res.CodeChallengeMethod,
+		&res.Nonce,
+		&res.AuthTime,
+		&res.ExpiresAt,
+		&res.UsedAt,
+		&res.CreatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"context"
+
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/postgres"
+	"medods/api-service/internal/usecase/client"
+)
+
+type clientRepo struct {
+	tableName string
+	db        *postgres.PostgresDB
+}
+
+func NewClientRepo(db *postgres.PostgresDB) client.ClientRepo {
+	return &clientRepo{
+		tableName: "clients",
+		db:        db,
+	}
+}
+
+func (r *clientRepo) Get(ctx context.Context, id string) (*entity.Client, error) {
+	query := r.db.Sq.Builder.
+		Select(
+			"id",
+			"name",
+			"secret_hash",
+			"scopes",
+			"redirect_uris",
+			"tenant",
+			"created_at",
+			"updated_at",
+		).
+		From(r.tableName).
+		Where(r.db.Sq.Equal("id", id))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" read")
+	}
+
+	var res entity.Client
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.ID,
+		&res.Name,
+		&res.SecretHash,
+		&res.Scopes,
+		&res.RedirectURIs,
+		&res.Tenant,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
+
+func (r *clientRepo) Create(ctx context.Context, m *entity.Client) error {
+	clauses := map[string]interface{}{
+		"id":            m.ID,
+		"name":          m.Name,
+		"secret_hash":   m.SecretHash,
+		"scopes":        m.Scopes,
+		"redirect_uris": m.RedirectURIs,
+		"tenant":        m.Tenant,
+		"created_at":    m.CreatedAt,
+		"updated_at":    m.UpdatedAt,
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.Insert(r.tableName).SetMap(clauses).ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" create")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"context"
+
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/postgres"
+	"medods/api-service/internal/usecase/consent"
+)
+
+type consentRepo struct {
+	tableName string
+	db        *postgres.PostgresDB
+}
+
+func NewConsentRepo(db *postgres.PostgresDB) consent.ConsentRepo {
+	return &consentRepo{
+		tableName: "oauth_consents",
+		db:        db,
+	}
+}
+
+func (r *consentRepo) Get(ctx context.Context, userID, clientID string) (*entity.Consent, error) {
+	query := r.db.Sq.Builder.
+		Select(
+			"user_id",
+			"client_id",
+			"scopes",
+			"created_at",
+			"updated_at",
+		).
+		From(r.tableName).
+		Where(r.db.Sq.EqualMany(map[string]interface{}{
+			"user_id":   userID,
+			"client_id": clientID,
+		}))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" read")
+	}
+
+	var res entity.Consent
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.UserID,
+		&res.ClientID,
+		&res.Scopes,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
+
+func (r *consentRepo) Upsert(ctx context.Context, m *entity.Consent) error {
+	clauses := map[string]interface{}{
+		"user_id":    m.UserID,
+		"client_id":  m.ClientID,
+		"scopes":     m.Scopes,
+		"created_at": m.CreatedAt,
+		"updated_at": m.UpdatedAt,
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.
+		Insert(r.tableName).
+		SetMap(clauses).
+		Suffix("ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, updated_at = EXCLUDED.updated_at").
+		ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" upsert")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
+	}
+	return nil
+}
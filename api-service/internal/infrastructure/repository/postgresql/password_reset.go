@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/postgres"
+	"medods/api-service/internal/usecase/password_reset"
+)
+
+type passwordResetRepo struct {
+	tableName string
+	db        *postgres.PostgresDB
+}
+
+func NewPasswordResetRepo(db *postgres.PostgresDB) password_reset.PasswordResetRepo {
+	return &passwordResetRepo{
+		tableName: "password_resets",
+		db:        db,
+	}
+}
+
+func (r *passwordResetRepo) Get(ctx context.Context, guid string) (*entity.PasswordReset, error) {
+	query := r.db.Sq.Builder.
+		Select(
+			"id",
+			"user_id",
+			"expires_at",
+			"used_at",
+			"created_at",
+		).
+		From(r.tableName).
+		Where(r.db.Sq.Equal("id", guid))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" read")
+	}
+
+	var res entity.PasswordReset
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.GUID,
+		&res.UserID,
+		&res.ExpiresAt,
+		&res.UsedAt,
+		&res.CreatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, m *entity.PasswordReset) error {
+	clauses := map[string]interface{}{
+		"id":         m.GUID,
+		"user_id":    m.UserID,
+		"expires_at": m.ExpiresAt,
+		"created_at": m.CreatedAt,
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.Insert(r.tableName).SetMap(clauses).ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" create")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
+	}
+	return nil
+}
+
+// MarkUsed sets used_at in a single statement so concurrent requests cannot use one record twice
+func (r *passwordResetRepo) MarkUsed(ctx context.Context, guid string, usedAt time.Time) (*entity.PasswordReset, error) {
+	sqlStr, args, err := r.db.Sq.Builder.
+		Update(r.tableName).
+		Set("used_at", usedAt).
+		Where(r.db.Sq.And(
+			r.db.Sq.Equal("id", guid),
+			r.db.Sq.Equal("used_at", nil),
+			r.db.Sq.Gt("expires_at", usedAt),
+		)).
+		Suffix("RETURNING id, user_id, expires_at, used_at, created_at").
+		ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" update")
+	}
+
+	var res entity.PasswordReset
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.GUID,
+		&res.UserID,
+		&res.ExpiresAt,
+		&res.UsedAt,
+		&res.CreatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,197 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"medods/api-service/internal/entity"
+	errorspkg "medods/api-service/internal/errors"
+	"medods/api-service/internal/pkg/postgres"
+	"medods/api-service/internal/usecase/user"
+)
+
+type userRepo struct {
+	tableName string
+	db        *postgres.PostgresDB
+}
+
+func NewUserRepo(db *postgres.PostgresDB) user.UserStore {
+	return &userRepo{
+		tableName: "users",
+		db:        db,
+	}
+}
+
+// errEmptyFilter keeps an empty filter from matching every user
+var errEmptyFilter = errors.New("user filter has no field set")
+
+// filter matches live users by the set field of the filter
+func (r *userRepo) filter(filter *entity.UserFilter) (sq.Sqlizer, error) {
+	if filter == nil || filter.ID == "" && filter.Email == "" {
+		return nil, errEmptyFilter
+	}
+	conditions := r.db.Sq.And(r.db.Sq.Equal("deleted_at", nil))
+	if filter.ID != "" {
+		conditions = append(conditions, r.db.Sq.Equal("id", filter.ID))
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, r.db.Sq.Equal("email", filter.Email))
+	}
+	return conditions, nil
+}
+
+func (r *userRepo) Get(ctx context.Context, filter *entity.UserFilter) (*entity.User, error) {
+	where, err := r.filter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.Sq.Builder.
+		Select(
+			"id",
+			"full_name",
+			"email",
+			"password",
+			"date_of_birth",
+			"profile_img",
+			"card",
+			"gender",
+			"phone_number",
+			"role",
+			"COALESCE(refresh_token, '')",
+			"created_at",
+			"updated_at",
+			"deleted_at",
+		).
+		From(r.tableName).
+		Where(where)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.tableName+" read")
+	}
+
+	var res entity.User
+	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
+		&res.ID,
+		&res.FullName,
+		&res.Email,
+		&res.Password,
+		&res.DateOfBirth,
+		&res.ProfileImg,
+		&res.Card,
+		&res.Gender,
+		&res.PhoneNumber,
+		&res.Role,
+		&res.RefreshToken,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+		&res.DeletedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &res, nil
+}
+
+func (r *userRepo) Create(ctx context.Context, m *entity.User) error {
+	clauses := map[string]interface{}{
+		"id":            m.ID,
+		"full_name":     m.FullName,
+		"email":         m.Email,
+		"password":      m.Password,
+		"date_of_birth": m.DateOfBirth,
+		"profile_img":   m.ProfileImg,
+		"card":          m.Card,
+		"gender":        m.Gender,
+		"phone_number":  m.PhoneNumber,
+		"role":          m.Role,
+		"created_at":    m.CreatedAt,
+		"updated_at":    m.UpdatedAt,
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.Insert(r.tableName).SetMap(clauses).ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" create")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
+	}
+	return nil
+}
+
+func (r *userRepo) Update(ctx context.Context, m *entity.User) error {
+	where, err := r.filter(&entity.UserFilter{ID: m.ID})
+	if err != nil {
+		return err
+	}
+
+	clauses := map[string]interface{}{
+		"updated_at": time.Now().UTC(),
+	}
+	for column, value := range map[string]string{
+		"full_name":     m.FullName,
+		"email":         m.Email,
+		"password":      m.Password,
+		"date_of_birth": m.DateOfBirth,
+		"profile_img":   m.ProfileImg,
+		"card":          m.Card,
+		"gender":        m.Gender,
+		"phone_number":  m.PhoneNumber,
+		"role":          m.Role,
+		"refresh_token": m.RefreshToken,
+	} {
+		if value != "" {
+			clauses[column] = value
+		}
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.
+		Update(r.tableName).
+		SetMap(clauses).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" update")
+	}
+
+	commandTag, err := r.db.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+	return nil
+}
+
+// Delete marks the user as deleted, deleted users are not found anymore
+func (r *userRepo) Delete(ctx context.Context, filter *entity.UserFilter) error {
+	where, err := r.filter(filter)
+	if err != nil {
+		return err
+	}
+
+	sqlStr, args, err := r.db.Sq.Builder.
+		Update(r.tableName).
+		Set("deleted_at", time.Now().UTC()).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" delete")
+	}
+
+	commandTag, err := r.db.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+	return nil
+}
@@ -7,3 +7,9 @@ const (
 	EnvironmentDevelop                       = "develop"
 	CtxKeyLocalization    ctxKeyLocalization = 0
 )
+
+const (
+	RoleAdmin        = "admin"
+	RoleUser         = "user"
+	RoleUnauthorized = "unauthorized"
+)
@@ -1,14 +1,12 @@
 package config
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"time"
 )
 
-const (
-	OtpSecret = "some_secret"
-)
-
 type webAddress struct {
 	Host string
 	Port string
@@ -19,11 +17,13 @@ type Config struct {
 	Environment string
 	LogLevel    string
 	Server      struct {
-		Host         string
-		Port         string
-		ReadTimeout  string
-		WriteTimeout string
-		IdleTimeout  string
+		Host            string
+		Port            string
+		ReadTimeout     time.Duration
+		WriteTimeout    time.Duration
+		IdleTimeout     time.Duration
+		ShutdownTimeout time.Duration
+		DrainDelay      time.Duration
 	}
 	DB struct {
 		Host     string
@@ -34,7 +34,7 @@ type Config struct {
 		SSLMode  string
 	}
 	Context struct {
-		Timeout string
+		Timeout time.Duration
 	}
 	Redis struct {
 		Host     string
@@ -43,72 +43,100 @@ type Config struct {
 		Name     string
 	}
 	Token struct {
-		Secret     string
-		AccessTTL  time.Duration
-		RefreshTTL time.Duration
-		SignInKey  string
+		AccessTTL       time.Duration
+		AdminAccessTTL  time.Duration
+		ClientAccessTTL time.Duration
+		RefreshTTL      time.Duration
+		ResetTTL        time.Duration
+		AuthCodeTTL     time.Duration
+		SignInKey       string
+		Issuer          string
+		OIDCKeyFile     string
+	}
+	Casbin struct {
+		ModelPath    string
+		CachePrefix  string
+		CacheTTL     time.Duration
+		CacheTimeout time.Duration
+	}
+	Tenant struct {
+		Default string
+	}
+	GRPC struct {
+		RetryMaxAttempts    int
+		RetryInitialBackoff time.Duration
+		RetryMaxBackoff     time.Duration
+		KeepaliveTime       time.Duration
+		KeepaliveTimeout    time.Duration
+		BreakerFailures     int
+		BreakerTimeout      time.Duration
+		TLSEnabled          bool
+		CAFile              string
+		CertFile            string
+		KeyFile             string
+		ServerNameOverride  string
 	}
-	UserService          webAddress
+	Health struct {
+		Timeout time.Duration
+	}
+	Tracing struct {
+		Exporter    string
+		Endpoint    string
+		Insecure    bool
+		SampleRatio float64
+	}
+	UserService webAddress
+	UserStore   string
 }
 
-func NewConfig() (*Config, error) {
+// Load layers the configuration, later layers win: defaults, the yaml or toml
+// file given by --config or CONFIG_FILE, env vars and flags named after the keys
+// of the file. The config is not validated so it can be printed first.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
 	var config Config
+	settings := config.settings()
 
-	// general configuration
-	config.APP = getEnv("APP", "app")
-	config.Environment = getEnv("ENVIRONMENT", "develop")
-	config.LogLevel = getEnv("LOG_LEVEL", "debug")
-	config.Context.Timeout = getEnv("CONTEXT_TIMEOUT", "7s")
-
-	// server configuration
-	config.Server.Host = getEnv("SERVER_HOST", "api-service")
-	config.Server.Port = getEnv("SERVER_PORT", ":1234")
-	config.Server.ReadTimeout = getEnv("SERVER_READ_TIMEOUT", "10s")
-	config.Server.WriteTimeout = getEnv("SERVER_WRITE_TIMEOUT", "10s")
-	config.Server.IdleTimeout = getEnv("SERVER_IDLE_TIMEOUT", "120s")
-
-	// db configuration
-	config.DB.Host = getEnv("POSTGRES_HOST", "postgres")
-	config.DB.Port = getEnv("POSTGRES_PORT", "5432")
-	config.DB.Name = getEnv("POSTGRES_DATABASE", "regauth")
-	config.DB.User = getEnv("POSTGRES_USER", "postgres")
-	config.DB.Password = getEnv("POSTGRES_PASSWORD", "qwerty")
-	config.DB.SSLMode = getEnv("POSTGRES_SSLMODE", "disable")
-
-	// redis configuration
-	config.Redis.Host = getEnv("REDIS_HOST", "redis-db")
-	config.Redis.Port = getEnv("REDIS_PORT", "6379")
-	config.Redis.Password = getEnv("REDIS_PASSWORD", "")
-	config.Redis.Name = getEnv("REDIS_DATABASE", "0")
-
-	// user configuration
-	config.UserService.Host = getEnv("USER_SERVICE_GRPC_HOST", "user-service")
-	config.UserService.Port = getEnv("USER_SERVICE_GRPC_PORT", ":4321")
-
-	// token configuration
-	config.Token.Secret = getEnv("TOKEN_SECRET", "token_secret")
-
-	// access ttl parse
-	accessTTl, err := time.ParseDuration(getEnv("TOKEN_ACCESS_TTL", "2h"))
-	if err != nil {
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "path of a yaml or toml config file")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.key] = fs.String(s.key, "", fmt.Sprintf("overrides %s (default %q)", s.env, s.def))
+	}
+	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
-	// refresh ttl parse
-	refreshTTL, err := time.ParseDuration(getEnv("TOKEN_REFRESH_TTL", "48h"))
-	if err != nil {
+
+	fileValues := map[string]string{}
+	if *file != "" {
+		var err error
+		fileValues, err = readFile(*file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := checkKeys(settings, fileValues, *file); err != nil {
 		return nil, err
 	}
-	config.Token.AccessTTL = accessTTl
-	config.Token.RefreshTTL = refreshTTL
-	config.Token.SignInKey = getEnv("TOKEN_SIGNIN_KEY", "debug_booking")
 
-	return &config, nil
-}
+	passed := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	for _, s := range settings {
+		raw, source := s.def, "default"
+		if value, ok := fileValues[s.key]; ok {
+			raw, source = value, *file
+		}
+		if value, ok := os.LookupEnv(s.env); ok {
+			raw, source = value, "env "+s.env
+		}
+		if passed[s.key] {
+			raw, source = *flags[s.key], "flag --"+s.key
+		}
+		if err := s.set(raw); err != nil {
+			return nil, fmt.Errorf("config %s from %s: %w", s.key, source, err)
+		}
 	}
 
-	return defaultValue
+	return &config, nil
 }
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// readFile flattens the yaml or toml file to the dotted keys of the settings
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading config file: %w", err)
+	}
+
+	tree := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sub, ok := value.(map[string]interface{}); ok {
+			flatten(key, sub, values)
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+// checkKeys refuses keys of the file no setting reads, they are most likely typos
+func checkKeys(settings []setting, values map[string]string, path string) error {
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.key] = true
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys in config file %s: %s", path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Redacted returns the config as a yaml file that can be loaded back, secrets that are set are redacted
+func (c *Config) Redacted() (string, error) {
+	tree := map[string]interface{}{}
+	for _, s := range c.settings() {
+		value := s.typed()
+		if s.secret && s.get() != "" {
+			value = redacted
+		}
+
+		node := tree
+		parts := strings.Split(s.key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := node[part].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				node[part] = sub
+			}
+			node = sub
+		}
+		node[parts[len(parts)-1]] = value
+	}
+
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// setting binds a field of the config to its key in the config file, which is
+// also the name of its flag, and to its env var
+type setting struct {
+	key    string
+	env    string
+	def    string
+	value  interface{}
+	secret bool
+}
+
+// settings lists every field of the config with its default
+func (c *Config) settings() []setting {
+	return []setting{
+		// general configuration
+		{key: "app", env: "APP", def: "app", value: &c.APP},
+		{key: "environment", env: "ENVIRONMENT", def: "develop", value: &c.Environment},
+		{key: "log_level", env: "LOG_LEVEL", def: "debug", value: &c.LogLevel},
+		{key: "context.timeout", env: "CONTEXT_TIMEOUT", def: "7s", value: &c.Context.Timeout},
+
+		// server configuration, readiness fails for the drain delay before the server
+		// stops accepting, then in-flight requests and notifications are drained for
+		// at most the shutdown timeout
+		{key: "server.host", env: "SERVER_HOST", def: "api-service", value: &c.Server.Host},
+		{key: "server.port", env: "SERVER_PORT", def: ":1234", value: &c.Server.Port},
+		{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", def: "10s", value: &c.Server.ReadTimeout},
+		{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", def: "10s", value: &c.Server.WriteTimeout},
+		{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", def: "120s", value: &c.Server.IdleTimeout},
+		{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT", def: "30s", value: &c.Server.ShutdownTimeout},
+		{key: "server.drain_delay", env: "SERVER_DRAIN_DELAY", def: "5s", value: &c.Server.DrainDelay},
+
+		// db configuration
+		{key: "postgres.host", env: "POSTGRES_HOST", def: "postgres", value: &c.DB.Host},
+		{key: "postgres.port", env: "POSTGRES_PORT", def: "5432", value: &c.DB.Port},
+		{key: "postgres.database", env: "POSTGRES_DATABASE", def: "regauth", value: &c.DB.Name},
+		{key: "postgres.user", env: "POSTGRES_USER", def: "postgres", value: &c.DB.User},
+		{key: "postgres.password", env: "POSTGRES_PASSWORD", def: "qwerty", value: &c.DB.Password, secret: true},
+		{key: "postgres.sslmode", env: "POSTGRES_SSLMODE", def: "disable", value: &c.DB.SSLMode},
+
+		// redis configuration
+		{key: "redis.host", env: "REDIS_HOST", def: "redis-db", value: &c.Redis.Host},
+		{key: "redis.port", env: "REDIS_PORT", def: "6379", value: &c.Redis.Port},
+		{key: "redis.password", env: "REDIS_PASSWORD", def: "", value: &c.Redis.Password, secret: true},
+		{key: "redis.database", env: "REDIS_DATABASE", def: "0", value: &c.Redis.Name},
+
+		// casbin configuration, a slow redis delays a request by at most a few cache timeouts
+		{key: "casbin.model_path", env: "CASBIN_MODEL_PATH", def: "", value: &c.Casbin.ModelPath},
+		{key: "casbin.cache_prefix", env: "CASBIN_CACHE_PREFIX", def: "casbin:decision:", value: &c.Casbin.CachePrefix},
+		{key: "casbin.cache_ttl", env: "CASBIN_CACHE_TTL", def: "10m", value: &c.Casbin.CacheTTL},
+		{key: "casbin.cache_timeout", env: "CASBIN_CACHE_TIMEOUT", def: "50ms", value: &c.Casbin.CacheTimeout},
+
+		// tenant configuration
+		{key: "tenant.default", env: "TENANT_DEFAULT", def: "default", value: &c.Tenant.Default},
+
+		// user configuration, users are read from the user service or straight from postgres
+		{key: "user_service.host", env: "USER_SERVICE_GRPC_HOST", def: "user-service", value: &c.UserService.Host},
+		{key: "user_service.port", env: "USER_SERVICE_GRPC_PORT", def: ":4321", value: &c.UserService.Port},
+		{key: "user_store", env: "USER_STORE", def: "grpc", value: &c.UserStore},
+
+		// grpc client configuration
+		{key: "grpc.retry_max_attempts", env: "GRPC_RETRY_MAX_ATTEMPTS", def: "3", value: &c.GRPC.RetryMaxAttempts},
+		{key: "grpc.retry_initial_backoff", env: "GRPC_RETRY_INITIAL_BACKOFF", def: "100ms", value: &c.GRPC.RetryInitialBackoff},
+		{key: "grpc.retry_max_backoff", env: "GRPC_RETRY_MAX_BACKOFF", def: "1s", value: &c.GRPC.RetryMaxBackoff},
+		{key: "grpc.keepalive_time", env: "GRPC_KEEPALIVE_TIME", def: "30s", value: &c.GRPC.KeepaliveTime},
+		{key: "grpc.keepalive_timeout", env: "GRPC_KEEPALIVE_TIMEOUT", def: "10s", value: &c.GRPC.KeepaliveTimeout},
+		{key: "grpc.breaker_failures", env: "GRPC_BREAKER_FAILURES", def: "5", value: &c.GRPC.BreakerFailures},
+		{key: "grpc.breaker_timeout", env: "GRPC_BREAKER_TIMEOUT", def: "30s", value: &c.GRPC.BreakerTimeout},
+
+		// grpc tls, a client certificate enables mutual tls
+		{key: "grpc.tls.enabled", env: "GRPC_TLS_ENABLED", def: "false", value: &c.GRPC.TLSEnabled},
+		{key: "grpc.tls.ca_file", env: "GRPC_TLS_CA_FILE", def: "", value: &c.GRPC.CAFile},
+		{key: "grpc.tls.cert_file", env: "GRPC_TLS_CERT_FILE", def: "", value: &c.GRPC.CertFile},
+		{key: "grpc.tls.key_file", env: "GRPC_TLS_KEY_FILE", def: "", value: &c.GRPC.KeyFile},
+		{key: "grpc.tls.server_name", env: "GRPC_TLS_SERVER_NAME", def: "", value: &c.GRPC.ServerNameOverride},
+
+		// health configuration, every readiness check is bounded by the timeout
+		{key: "health.timeout", env: "HEALTH_CHECK_TIMEOUT", def: "2s", value: &c.Health.Timeout},
+
+		// tracing configuration, the exporter is one of otlp, stdout or none
+		{key: "tracing.exporter", env: "OTEL_TRACES_EXPORTER", def: "none", value: &c.Tracing.Exporter},
+		{key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", def: "otel-collector:4317", value: &c.Tracing.Endpoint},
+		{key: "tracing.insecure", env: "OTEL_EXPORTER_OTLP_INSECURE", def: "true", value: &c.Tracing.Insecure},
+		{key: "tracing.sample_ratio", env: "OTEL_TRACES_SAMPLER_ARG", def: "1", value: &c.Tracing.SampleRatio},
+
+		// token configuration
+		{key: "token.signin_key", env: "TOKEN_SIGNIN_KEY", def: "debug_booking", value: &c.Token.SignInKey, secret: true},
+		{key: "token.access_ttl", env: "TOKEN_ACCESS_TTL", def: "2h", value: &c.Token.AccessTTL},
+		{key: "token.admin_access_ttl", env: "TOKEN_ADMIN_ACCESS_TTL", def: "15m", value: &c.Token.AdminAccessTTL},
+		{key: "token.client_access_ttl", env: "TOKEN_CLIENT_ACCESS_TTL", def: "1h", value: &c.Token.ClientAccessTTL},
+		{key: "token.refresh_ttl", env: "TOKEN_REFRESH_TTL", def: "48h", value: &c.Token.RefreshTTL},
+		{key: "token.reset_ttl", env: "TOKEN_RESET_TTL", def: "15m", value: &c.Token.ResetTTL},
+		{key: "token.auth_code_ttl", env: "TOKEN_AUTH_CODE_TTL", def: "5m", value: &c.Token.AuthCodeTTL},
+		{key: "token.issuer", env: "TOKEN_ISSUER", def: "http://localhost:1234", value: &c.Token.Issuer},
+		{key: "token.oidc_key_file", env: "TOKEN_OIDC_KEY_FILE", def: "", value: &c.Token.OIDCKeyFile},
+	}
+}
+
+func (s setting) set(raw string) error {
+	switch value := s.value.(type) {
+	case *string:
+		*value = raw
+	case *int:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*value = v
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		*value = v
+	case *float64:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		*value = v
+	case *time.Duration:
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*value = v
+	default:
+		return fmt.Errorf("unsupported type %T", s.value)
+	}
+	return nil
+}
+
+// get returns the value in the format set parses, so printed configs can be loaded back
+func (s setting) get() string {
+	switch value := s.value.(type) {
+	case *string:
+		return *value
+	case *int:
+		return strconv.Itoa(*value)
+	case *bool:
+		return strconv.FormatBool(*value)
+	case *float64:
+		return strconv.FormatFloat(*value, 'g', -1, 64)
+	case *time.Duration:
+		return value.String()
+	default:
+		return ""
+	}
+}
+
+// typed returns the value for printing, durations are kept in the format set parses
+func (s setting) typed() interface{} {
+	switch value := s.value.(type) {
+	case *int:
+		return *value
+	case *bool:
+		return *value
+	case *float64:
+		return *value
+	default:
+		return s.get()
+	}
+}
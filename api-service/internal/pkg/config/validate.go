@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"medods/api-service/internal/pkg/app"
+)
+
+// Validate reports every invalid setting at once, production refuses to start
+// with the secrets left at their defaults
+func (c *Config) Validate() error {
+	var errs []error
+
+	for _, s := range c.settings() {
+		if duration, ok := s.value.(*time.Duration); ok && *duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", s.key))
+		}
+		if c.Environment == app.EnvironmentProduction && s.secret && s.def != "" && s.get() == s.def {
+			errs = append(errs, fmt.Errorf("%s must not be left at its default in production, set %s", s.key, s.env))
+		}
+	}
+	if c.Environment == app.EnvironmentProduction {
+		if c.Token.SignInKey == "" {
+			errs = append(errs, errors.New("token.signin_key must be set in production"))
+		}
+		// an ephemeral key would change on every restart and differ between replicas
+		if c.Token.OIDCKeyFile == "" {
+			errs = append(errs, errors.New("token.oidc_key_file must be set in production, set TOKEN_OIDC_KEY_FILE"))
+		}
+		// user-service is only dialed for the grpc user store
+		if c.UserStore == "grpc" && !c.GRPC.TLSEnabled {
+			errs = append(errs, errors.New("grpc.tls.enabled must be true in production, set GRPC_TLS_ENABLED"))
+		}
+	}
+	if (c.GRPC.CertFile == "") != (c.GRPC.KeyFile == "") {
+		errs = append(errs, errors.New("grpc.tls.cert_file and grpc.tls.key_file must be set together"))
+	}
+
+	switch c.UserStore {
+	case "grpc", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("user_store must be grpc or postgres, got %q", c.UserStore))
+	}
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none", "":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be otlp, stdout or none, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+	if c.GRPC.RetryMaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("grpc.retry_max_attempts must be at least 1, got %d", c.GRPC.RetryMaxAttempts))
+	}
+	if c.GRPC.BreakerFailures < 1 {
+		errs = append(errs, fmt.Errorf("grpc.breaker_failures must be at least 1, got %d", c.GRPC.BreakerFailures))
+	}
+
+	return errors.Join(errs...)
+}
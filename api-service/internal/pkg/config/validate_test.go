@@ -0,0 +1,46 @@
+package config
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestValidateProduction(t *testing.T) {
+	load := func(args ...string) error {
+		t.Helper()
+		cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), append([]string{"--environment=production"}, args...))
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return cfg.Validate()
+	}
+
+	required := []string{"token.oidc_key_file", "grpc.tls.enabled"}
+
+	err := load()
+	if err == nil {
+		t.Fatal("production config with the defaults is valid")
+	}
+	for _, key := range required {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Validate() = %v, want an error for %s", err, key)
+		}
+	}
+
+	// without user-service there is no grpc connection to protect
+	err = load("--user_store=postgres")
+	if err != nil && strings.Contains(err.Error(), "grpc.tls.enabled") {
+		t.Errorf("Validate() = %v, want no grpc tls error for the postgres user store", err)
+	}
+
+	err = load("--token.oidc_key_file=/etc/api-service/oidc.pem", "--grpc.tls.enabled=true", "--grpc.tls.cert_file=/etc/api-service/client.pem")
+	for _, key := range required {
+		if err != nil && strings.Contains(err.Error(), key+" must") {
+			t.Errorf("Validate() = %v, want no error for %s once it is set", err, key)
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "grpc.tls.cert_file and grpc.tls.key_file") {
+		t.Errorf("Validate() = %v, want an error for a client certificate without a key", err)
+	}
+}
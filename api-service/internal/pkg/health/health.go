@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check returns an error when the dependency can not serve requests
+type Check func(ctx context.Context) error
+
+type Status struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health runs the readiness checks of the dependencies, each one bounded by the timeout
+type Health struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// Add registers a check, it is not safe to add checks once requests are served
+func (h *Health) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown fails readiness so the orchestrator stops routing traffic to the replica
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs the checks concurrently, the replica is ready when every check passes
+func (h *Health) Ready(ctx context.Context) (bool, map[string]Status) {
+	statuses := make(map[string]Status, len(h.checks)+1)
+	if h.shuttingDown.Load() {
+		statuses["server"] = Status{Status: StatusDown, Error: ErrShuttingDown.Error()}
+		return false, statuses
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	ready := true
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			status := h.run(ctx, c.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[c.name] = status
+			if status.Status != StatusUp {
+				ready = false
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return ready, statuses
+}
+
+// run does not wait for checks ignoring the context past the timeout
+func (h *Health) run(ctx context.Context, check Check) Status {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := Status{Status: StatusUp, Latency: time.Since(start).String()}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
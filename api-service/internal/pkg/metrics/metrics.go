@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "api"
+
+// refresh failure reasons
+const (
+	RefreshInvalidToken = "invalid_token"
+	RefreshUnknownUser  = "unknown_user"
+	RefreshTokenReused  = "token_mismatch"
+	RefreshNotMember    = "not_member"
+	RefreshInternal     = "internal"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of http requests by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	TokensIssued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "tokens_issued_total",
+		Help:      "Number of access tokens issued by grant.",
+	}, []string{"grant"})
+
+	Refreshes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "refreshes_total",
+		Help:      "Number of successful token refreshes.",
+	})
+
+	RefreshFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "refresh_failures_total",
+		Help:      "Number of failed token refreshes by reason.",
+	}, []string{"reason"})
+
+	IPMismatches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "ip_mismatches_total",
+		Help:      "Number of refreshes from another ip than the one the token was issued to.",
+	})
+
+	Emails = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "email",
+		Name:      "sent_total",
+		Help:      "Number of emails by result, sent or failed.",
+	}, []string{"result"})
+
+	CasbinDenials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "casbin",
+		Name:      "denials_total",
+		Help:      "Number of requests denied by casbin by route template and status.",
+	}, []string{"route", "status"})
+
+	GRPCClientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of outgoing grpc calls by method and code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// EmailResult returns the result label of a sent email
+func EmailResult(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "sent"
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Notifier sends notifications in the background, shutdown flushes the ones in
+// flight instead of dropping them with the process
+type Notifier struct {
+	wg     sync.WaitGroup
+	logger *zap.Logger
+}
+
+func New(logger *zap.Logger) *Notifier {
+	return &Notifier{logger: logger}
+}
+
+// Go runs send in the background, a failure is logged with the name of the notification
+func (n *Notifier) Go(name string, send func() error) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := send(); err != nil {
+			n.logger.Error("failed to send notification", zap.String("notification", name), zap.Error(err))
+		}
+	}()
+}
+
+// Flush waits for the notifications in flight, or returns the error of ctx when it is done first
+func (n *Notifier) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -2,43 +2,139 @@ package policy
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/casbin/casbin/v2/persist/cache"
-	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
+// clearBatchSize is the number of keys scanned and deleted per pipeline on Clear
+const clearBatchSize = 500
+
+var cacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "casbin",
+	Subsystem: "cache",
+	Name:      "errors_total",
+	Help:      "Number of failed casbin decision cache operations.",
+}, []string{"operation"})
+
+// casbinCache keeps enforcer decisions in redis so replicas share them,
+// every key is namespaced with the prefix and expires after the ttl. Reads and
+// writes give up after the timeout, the decision is then evaluated locally.
 type casbinCache struct {
-	db *redis.Client
+	db      *redis.Client
+	prefix  string
+	ttl     time.Duration
+	timeout time.Duration
+	logger  *zap.Logger
 }
 
-func NewCache(db *redis.Client) *casbinCache {
+func NewCache(db *redis.Client, prefix string, ttl, timeout time.Duration, logger *zap.Logger) *casbinCache {
 	return &casbinCache{
-		db: db,
+		db:      db,
+		prefix:  prefix,
+		ttl:     ttl,
+		timeout: timeout,
+		logger:  logger,
 	}
 }
 
+// Set stores the decision, the enforcer passes its expire time as the first extra value.
+// A failed write is reported but not returned, the decision itself is still valid.
 func (c *casbinCache) Set(key string, value bool, extra ...interface{}) error {
-	var expiration time.Duration = 0
-	c.db.Set(context.Background(), key, value, expiration)
+	expiration := c.ttl
+	if len(extra) != 0 {
+		if ttl, ok := extra[0].(time.Duration); ok && ttl > 0 {
+			expiration = ttl
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if err := c.db.Set(ctx, c.prefix+key, value, expiration).Err(); err != nil {
+		cacheErrors.WithLabelValues("set").Inc()
+		c.logger.Warn("casbin cache set", zap.Error(err))
+	}
 	return nil
 }
 
+// Get returns cache.ErrNoSuchKey on misses and on redis errors, so the enforcer evaluates the request
 func (c *casbinCache) Get(key string) (bool, error) {
-	res, err := c.db.Get(context.Background(), key).Bool()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	res, err := c.db.Get(ctx, c.prefix+key).Bool()
 	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			cacheErrors.WithLabelValues("get").Inc()
+			c.logger.Warn("casbin cache get", zap.Error(err))
+		}
 		return false, cache.ErrNoSuchKey
 	}
 	return res, nil
 }
 
 func (c *casbinCache) Delete(key string) error {
-	if res := c.db.Del(context.Background(), key); res.Err() != nil {
-		return cache.ErrNoSuchKey
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if err := c.db.Del(ctx, c.prefix+key).Err(); err != nil {
+		cacheErrors.WithLabelValues("delete").Inc()
+		return err
 	}
 	return nil
 }
 
+// Clear deletes the keys under the prefix only, other data in the redis database
+// is kept. Every scan and unlink round trip is bounded by the cache timeout so
+// a stalled redis does not hang policy writes.
 func (c *casbinCache) Clear() error {
-	return c.db.FlushAll(context.Background()).Err()
+	var cursor uint64
+	for {
+		keys, next, err := c.scan(cursor)
+		if err != nil {
+			cacheErrors.WithLabelValues("clear").Inc()
+			return err
+		}
+		if err := c.unlink(keys); err != nil {
+			return err
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// scan returns a batch of the keys under the prefix and the cursor of the next one, 0 after the last
+func (c *casbinCache) scan(cursor uint64) ([]string, uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	return c.db.Scan(ctx, cursor, c.prefix+"*", clearBatchSize).Result()
+}
+
+// unlink deletes the keys in one pipeline, redis frees the memory in the background
+func (c *casbinCache) unlink(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	pipe := c.db.Pipeline()
+	for _, key := range keys {
+		pipe.Unlink(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		cacheErrors.WithLabelValues("clear").Inc()
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"medods/api-service/internal/pkg/config"
+)
+
+// stalledRedis accepts connections and never answers
+func stalledRedis(t *testing.T) (host, port string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(lis.Addr().String())
+	return host, port
+}
+
+func TestCacheTimeout(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Redis.Host, cfg.Redis.Port = stalledRedis(t)
+	db := NewRedisClient(cfg)
+	defer db.Close()
+
+	const timeout = 50 * time.Millisecond
+	c := NewCache(db, "casbin:decision:", time.Minute, timeout, zap.NewNop())
+
+	for name, call := range map[string]func() error{
+		"get": func() error {
+			_, err := c.Get("key")
+			return err
+		},
+		"set":    func() error { return c.Set("key", true) },
+		"delete": func() error { return c.Delete("key") },
+		"clear":  c.Clear,
+	} {
+		start := time.Now()
+		err := call()
+		if elapsed := time.Since(start); elapsed > 10*timeout {
+			t.Errorf("%s took %v on a stalled redis, want about %v", name, elapsed, timeout)
+		}
+		if name == "clear" && err == nil {
+			t.Errorf("clear on a stalled redis succeeded")
+		}
+	}
+}
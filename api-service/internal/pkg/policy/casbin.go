@@ -6,41 +6,73 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/casbin/casbin/v2"
-	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/casbin/casbin/v2/util"
 	rediswatcher "github.com/casbin/redis-watcher/v2"
+	"github.com/spf13/cast"
 	"go.uber.org/zap"
 
 	"medods/api-service/internal/pkg/config"
 	"medods/api-service/internal/pkg/postgres"
 )
 
-func NewCachedEnforcer(cfg *config.Config, logger *zap.Logger) (*casbin.CachedEnforcer, error) {
+// NewRedisClient returns the client of the decision cache, the deadlines of
+// the contexts bound the calls so the cache timeout applies
+func NewRedisClient(cfg *config.Config) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:                  fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
+		Password:              cfg.Redis.Password,
+		DB:                    cast.ToInt(cfg.Redis.Name),
+		ContextTimeoutEnabled: true,
+	})
+}
+
+// NewCachedEnforcer returns the enforcer and a function closing the policy
+// database and watcher connections it holds
+func NewCachedEnforcer(cfg *config.Config, logger *zap.Logger, db *redis.Client) (*casbin.CachedEnforcer, func(), error) {
 	// initializing casbin model
-	m := model.NewModel()
-	m.AddDef("r", "r", "sub, obj, act")
-	m.AddDef("p", "p", "sub, obj, act")
-	m.AddDef("g", "g", "_, _")
-	m.AddDef("e", "e", "some(where (p.eft == allow))")
-	m.AddDef("m", "m", "g(r.sub, p.sub) && keyMatch4(r.obj, p.obj) && regexMatch(r.act, p.act)")
+	m, err := NewModel(cfg.Casbin.ModelPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewCachedEnforcer NewModel: %w", err)
+	}
 	//initializing pgx adapter
 	adapter, err := postgres.GetAdapter(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("NewCachedEnforcer GetAdapter: %w", err)
+		return nil, nil, fmt.Errorf("NewCachedEnforcer GetAdapter: %w", err)
 	}
 	enforcer, err := casbin.NewCachedEnforcer(m, adapter)
 	if err != nil {
-		return nil, fmt.Errorf("NewCachedEnforcer: %w", err)
+		adapter.Close()
+		return nil, nil, fmt.Errorf("NewCachedEnforcer: %w", err)
 	}
+	matchSharedGrants(enforcer.Enforcer)
+	// decisions are cached in redis and shared between replicas
+	initializingCache(cfg, logger, enforcer, db)
 	// initializing watcher
-	err = initializingWatcher(cfg, logger, enforcer)
+	w, err := initializingWatcher(cfg, logger, enforcer)
 	if err != nil {
-		return nil, fmt.Errorf("NewCachedEnforcer: %w", err)
+		adapter.Close()
+		return nil, nil, fmt.Errorf("NewCachedEnforcer: %w", err)
 	}
-	return enforcer, nil
+	return enforcer, func() {
+		w.Close()
+		adapter.Close()
+	}, nil
 }
 
-func initializingWatcher(cfg *config.Config, logger *zap.Logger, enforcer *casbin.CachedEnforcer) error {
-	w, _ := rediswatcher.NewWatcher(fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port), rediswatcher.WatcherOptions{
+// matchSharedGrants makes role grants of the "*" tenant apply in every tenant
+func matchSharedGrants(enforcer *casbin.Enforcer) {
+	enforcer.AddNamedDomainMatchingFunc("g", "keyMatch", util.KeyMatch)
+}
+
+func initializingCache(cfg *config.Config, logger *zap.Logger, enforcer *casbin.CachedEnforcer, db *redis.Client) {
+	enforcer.SetCache(NewCache(db, cfg.Casbin.CachePrefix, cfg.Casbin.CacheTTL, cfg.Casbin.CacheTimeout, logger))
+	enforcer.SetExpireTime(cfg.Casbin.CacheTTL)
+}
+
+// initializingWatcher reloads the policy when another replica changes it
+func initializingWatcher(cfg *config.Config, logger *zap.Logger, enforcer *casbin.CachedEnforcer) (persist.Watcher, error) {
+	w, err := rediswatcher.NewWatcher(fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port), rediswatcher.WatcherOptions{
 		Options: redis.Options{
 			Network:  "tcp",
 			Password: cfg.Redis.Password,
@@ -48,20 +80,26 @@ func initializingWatcher(cfg *config.Config, logger *zap.Logger, enforcer *casbi
 		IgnoreSelf: true,
 		Channel:    "/casbin_watcher",
 	})
+	if err != nil {
+		return nil, fmt.Errorf("NewWatcher: %w", err)
+	}
 	// set the watcher for the enforcer.
-	err := enforcer.SetWatcher(w)
+	err = enforcer.SetWatcher(w)
 	if err != nil {
-		return fmt.Errorf("SetWatcher: %w", err)
+		w.Close()
+		return nil, fmt.Errorf("SetWatcher: %w", err)
 	}
 	// set callback
 	err = w.SetUpdateCallback(func(s string) {
 		if err := enforcer.LoadPolicy(); err != nil {
 			logger.Error("enforcer watcher LoadPolicy", zap.Error(err))
+			return
 		}
 		logger.Info("enforcer watcher", zap.String("callback", s))
 	})
 	if err != nil {
-		return fmt.Errorf("SetUpdateCallback: %w", err)
+		w.Close()
+		return nil, fmt.Errorf("SetUpdateCallback: %w", err)
 	}
-	return nil
+	return w, nil
 }
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"github.com/casbin/casbin/v2/model"
+)
+
+// Model is the canonical casbin model of the api gateway.
+//
+// Requests are (Subject, tenant, Object, action). Object paths are matched
+// with keyMatch3, so policies may use "{param}" segments and a trailing "/*".
+// Policy subjects are roles, service clients or any subject a role was
+// granted to through g rules. Rules of the "owner" pseudo subject match only
+// when the caller owns the resource. Decisions are cached per route, so
+// policies name route patterns rather than single resources. Policies and role grants belong to a
+// tenant, the "*" tenant holds the rules shared by every tenant. The p2
+// section holds step-up authentication rules and is not part of the matcher.
+const Model = `
+[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+p2 = obj, act, max_age, acr
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = (g(r.sub.Role, p.sub, r.dom) || p.sub == "owner" && r.obj.Owned) \
+    && (p.dom == r.dom || p.dom == "*") && keyMatch3(r.obj.Path, p.obj) && (r.act == p.act || p.act == "*")
+`
+
+// NewModel returns the canonical model, or the model from path when it is set
+func NewModel(path string) (model.Model, error) {
+	if path != "" {
+		return model.NewModelFromFile(path)
+	}
+	return model.NewModelFromString(Model)
+}
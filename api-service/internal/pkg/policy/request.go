@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+)
+
+const (
+	// OwnerSubject is the pseudo subject of rules that only the owner of the resource matches
+	OwnerSubject = "owner"
+	// AnyTenant is the tenant of policies and role grants shared by every tenant
+	AnyTenant = "*"
+)
+
+// ownerParams maps owned resource patterns to the path segment holding the owner id
+var ownerParams = map[string]string{
+	"/v1/users/{id}": "{id}",
+}
+
+// Subject is the caller side of a casbin request
+type Subject struct {
+	Role   string
+	Tenant string
+}
+
+// GetCacheKey makes the subject usable with the CachedEnforcer
+func (s Subject) GetCacheKey() string {
+	return s.Role + "|" + s.Tenant
+}
+
+// Object is the resource side of a casbin request. Route is the route pattern
+// the path matched, empty when it matched none, and Owned is set when the caller
+// owns the resource.
+type Object struct {
+	Path  string
+	Route string
+	Owned bool
+}
+
+// GetCacheKey makes the object usable with the CachedEnforcer. Decisions are
+// cached per route so keys hold no ids or secrets of the path and their number
+// is bounded, paths without a route are only keyed by their digest.
+func (o Object) GetCacheKey() string {
+	key := o.Route
+	if key == "" {
+		sum := sha256.Sum256([]byte(o.Path))
+		key = hex.EncodeToString(sum[:])
+	}
+	return key + "|" + strconv.FormatBool(o.Owned)
+}
+
+// NewObject returns the object of a request path matched by route, userID is
+// the id of the calling user and empty for other callers
+func NewObject(path, route, userID string) Object {
+	owner := ResourceOwner(path)
+	return Object{
+		Path:  path,
+		Route: route,
+		Owned: owner != "" && owner == userID,
+	}
+}
+
+// Enforce decides whether sub may do act on obj in the tenant of sub, decisions
+// on paths outside of any route are not cached
+func Enforce(enforcer *casbin.CachedEnforcer, sub Subject, obj Object, act string) (bool, error) {
+	if obj.Route == "" {
+		return enforcer.Enforcer.Enforce(sub, sub.Tenant, obj, act)
+	}
+	return enforcer.Enforce(sub, sub.Tenant, obj, act)
+}
+
+// ResourceOwner returns the id of the user owning the resource at path
+func ResourceOwner(path string) string {
+	segments := strings.Split(path, "/")
+	for pattern, param := range ownerParams {
+		patternSegments := strings.Split(pattern, "/")
+		if len(patternSegments) != len(segments) {
+			continue
+		}
+
+		owner, matched := "", true
+		for i, segment := range patternSegments {
+			if segment == param {
+				owner = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched && owner != "" {
+			return owner
+		}
+	}
+	return ""
+}
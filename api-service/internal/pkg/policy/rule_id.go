@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/mmcloughlin/meow"
+
+	"medods/api-service/internal/pkg/postgres"
+)
+
+// RuleID returns the id the pgx adapter stores a rule under and removes it by
+func RuleID(ptype string, rule []string) string {
+	data := strings.Join(append([]string{ptype}, rule...), ",")
+	return fmt.Sprintf("%x", meow.Checksum(0, []byte(data)))
+}
+
+// SyncRuleIDs recomputes the ids of rules rewritten by sql migrations, which can
+// not compute them, so the policy api can remove those rules again. A rule that
+// is already stored under its id is a duplicate and is deleted.
+func SyncRuleIDs(ctx context.Context, db *postgres.PostgresDB) error {
+	rows, err := db.Query(ctx, "SELECT id, p_type, v0, v1, v2, v3, v4, v5 FROM casbin_rule")
+	if err != nil {
+		return fmt.Errorf("SyncRuleIDs select: %w", err)
+	}
+
+	stale := map[string]string{}
+	for rows.Next() {
+		var (
+			id, ptype string
+			values    [6]pgtype.Text
+		)
+		if err := rows.Scan(&id, &ptype, &values[0], &values[1], &values[2], &values[3], &values[4], &values[5]); err != nil {
+			rows.Close()
+			return fmt.Errorf("SyncRuleIDs scan: %w", err)
+		}
+
+		var rule []string
+		for _, value := range values {
+			if value.Status != pgtype.Present {
+				break
+			}
+			rule = append(rule, value.String)
+		}
+		if want := RuleID(ptype, rule); want != id {
+			stale[id] = want
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("SyncRuleIDs rows: %w", err)
+	}
+
+	for id, want := range stale {
+		if _, err := db.Exec(ctx,
+			"UPDATE casbin_rule SET id = $1 WHERE id = $2 AND NOT EXISTS (SELECT 1 FROM casbin_rule WHERE id = $1)",
+			want, id,
+		); err != nil {
+			return fmt.Errorf("SyncRuleIDs update: %w", err)
+		}
+		if _, err := db.Exec(ctx, "DELETE FROM casbin_rule WHERE id = $1", id); err != nil {
+			return fmt.Errorf("SyncRuleIDs delete: %w", err)
+		}
+	}
+	return nil
+}
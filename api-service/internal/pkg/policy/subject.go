@@ -0,0 +1,9 @@
+package policy
+
+const ClientSubjectPrefix = "client:"
+
+// ClientSubject returns the casbin subject of a service client, prefixed so
+// that client ids cannot collide with role names
+func ClientSubject(clientID string) string {
+	return ClientSubjectPrefix + clientID
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"medods/api-service/internal/pkg/tracing"
+)
+
+// pgx v4 has no tracer hook, the pool methods used by the repositories are
+// wrapped instead so every query gets a client span
+
+func (p *PostgresDB) startSpan(ctx context.Context, name, sql string) (context.Context, trace.Span) {
+	ctx, span := tracing.Start(ctx, name,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBStatement(sql),
+		attribute.String("db.name", p.Pool.Config().ConnConfig.Database),
+	)
+	return ctx, span
+}
+
+func (p *PostgresDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := p.startSpan(ctx, "postgres.exec", sql)
+	tag, err := p.Pool.Exec(ctx, sql, args...)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+	}
+	tracing.End(span, err)
+	return tag, err
+}
+
+func (p *PostgresDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := p.startSpan(ctx, "postgres.query", sql)
+	rows, err := p.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (p *PostgresDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := p.startSpan(ctx, "postgres.query_row", sql)
+	return &tracedRow{row: p.Pool.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracedRows ends the span once the rows are read to the end or closed
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+	once sync.Once
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	r.once.Do(func() {
+		err := r.Rows.Err()
+		if err == nil {
+			r.span.SetAttributes(attribute.Int64("db.rows_affected", r.Rows.CommandTag().RowsAffected()))
+		}
+		tracing.End(r.span, err)
+	})
+}
+
+// tracedRow ends the span once the row is scanned, no rows is not an error of the query
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if err == pgx.ErrNoRows {
+		tracing.End(r.span, nil)
+		return err
+	}
+	tracing.End(r.span, err)
+	return err
+}
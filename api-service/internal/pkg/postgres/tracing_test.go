@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+
+	"medods/api-service/internal/pkg/tracing/tracingtest"
+)
+
+func TestQuerySpans(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	// nothing listens on the port, every query fails to connect
+	poolConfig, err := pgxpool.ParseConfig("host=127.0.0.1 port=1 dbname=api connect_timeout=1")
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	poolConfig.LazyConnect = true
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatalf("ConnectConfig: %v", err)
+	}
+	db := &PostgresDB{Pool: pool, Sq: NewSquirrel()}
+	defer db.Close()
+
+	const sql = "UPDATE users SET updated_at = now() WHERE id = $1"
+	if _, err := db.Exec(context.Background(), sql, "1"); err == nil {
+		t.Fatal("Exec succeeded without a database")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "postgres.exec" {
+		t.Fatalf("spans = %v, want one postgres.exec span", spans)
+	}
+	span := spans[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want %v", span.Status.Code, codes.Error)
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range span.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(semconv.DBStatementKey)] != sql || attrs["db.name"] != "api" {
+		t.Errorf("span attributes = %v, want the statement and the database", attrs)
+	}
+}
@@ -0,0 +1,76 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const IDTokenSigningAlg = "RS256"
+
+// OIDCKey signs id tokens, clients verify them with the public key published as a JWKS
+type OIDCKey struct {
+	private *rsa.PrivateKey
+	KeyID   string
+}
+
+// LoadOIDCKey reads a PEM encoded RSA private key, an empty file name
+// generates an ephemeral key which is only suitable for development
+func LoadOIDCKey(file string) (*OIDCKey, error) {
+	var (
+		private *rsa.PrivateKey
+		err     error
+	)
+
+	if file == "" {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("error while generating oidc key: %w", err)
+		}
+	} else {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading oidc key: %w", err)
+		}
+		private, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing oidc key: %w", err)
+		}
+	}
+
+	// key id is derived from the public key so it stays stable across restarts
+	sum := sha256.Sum256(private.PublicKey.N.Bytes())
+
+	return &OIDCKey{
+		private: private,
+		KeyID:   base64.RawURLEncoding.EncodeToString(sum[:8]),
+	}, nil
+}
+
+func (k *OIDCKey) SignIDToken(claims jwt.MapClaims) (string, error) {
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = k.KeyID
+	return idToken.SignedString(k.private)
+}
+
+// JWKS returns the public key set in the RFC 7517 format
+func (k *OIDCKey) JWKS() map[string]interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": IDTokenSigningAlg,
+				"kid": k.KeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(k.private.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.private.PublicKey.E)).Bytes()),
+			},
+		},
+	}
+}
@@ -1,14 +1,100 @@
 package tokens
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
 	"medods/api-service/internal/pkg/logger"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+const (
+	// token types, only access tokens are accepted on protected routes
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	TypeReset   = "reset"
+
+	// audiences separate tokens of the user facing api from the admin api,
+	// tokens issued to a client must also carry the scope named after the api
+	AudienceUser  = "user-api"
+	AudienceAdmin = "admin-api"
+
+	defaultAccessTimeout  = time.Hour * 200
+	defaultRefreshTimeout = time.Hour * 400
+
+	// authentication context class references, ordered from weakest to strongest
+	ACRPassword = "pwd"
+	ACRMFA      = "mfa"
+)
+
+var acrLevels = map[string]int{
+	ACRPassword: 1,
+	ACRMFA:      2,
+}
+
+// HasAudience reports whether the aud claim, a string or a list, contains audience
+func HasAudience(claims jwt.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasType reports whether the typ claim of the token is typ
+func HasType(claims jwt.MapClaims, typ string) bool {
+	t, _ := claims["typ"].(string)
+	return t == typ
+}
+
+// IsClientToken reports whether the token was issued to a service client itself
+// rather than to a client acting on behalf of a user
+func IsClientToken(claims jwt.MapClaims) bool {
+	clientID, _ := claims["client_id"].(string)
+	return clientID != "" && claims["sub"] == clientID
+}
+
+// Tenant returns the tenant claim, tokens issued before tenants existed belong to defaultTenant
+func Tenant(claims jwt.MapClaims, defaultTenant string) string {
+	if tenant, _ := claims["tenant"].(string); tenant != "" {
+		return tenant
+	}
+	return defaultTenant
+}
+
+// Scopes splits the space separated scope claim
+func Scopes(claims jwt.MapClaims) []string {
+	scope, _ := claims["scope"].(string)
+	return strings.Fields(scope)
+}
+
+// ScopeAudiences returns the audiences the scopes grant access to
+func ScopeAudiences(scopes []string) []string {
+	var audiences []string
+	for _, scope := range scopes {
+		if scope == AudienceUser || scope == AudienceAdmin {
+			audiences = append(audiences, scope)
+		}
+	}
+	return audiences
+}
+
+// ACRSatisfies reports whether the token acr is at least as strong as the required one
+func ACRSatisfies(acr, required string) bool {
+	return acrLevels[acr] >= acrLevels[required]
+}
+
 type JwtHandler struct {
 	Sub       string
 	Iss       string
@@ -16,10 +102,18 @@ type JwtHandler struct {
 	Iat       string
 	Aud       []string
 	Role      string
+	Tenant    string
+	SessionID string
+	ClientID  string
+	Scopes    []string
+	AuthTime  int64
+	Acr       string
 	Token     string
 	SigninKey string
 	Log       *zap.Logger
 	Timeout   int
+	// RefreshTimeout is the lifetime of the refresh token
+	RefreshTimeout int
 }
 
 func (jwtHandler *JwtHandler) GenerateJwt() (access, refresh string, err error) {
@@ -31,12 +125,37 @@ func (jwtHandler *JwtHandler) GenerateJwt() (access, refresh string, err error)
 	accessToken = jwt.New(jwt.SigningMethodHS256)
 	refreshToken = jwt.New(jwt.SigningMethodHS256)
 
+	accessTimeout := defaultAccessTimeout
+	if jwtHandler.Timeout > 0 {
+		accessTimeout = time.Duration(jwtHandler.Timeout)
+	}
+	refreshTimeout := defaultRefreshTimeout
+	if jwtHandler.RefreshTimeout > 0 {
+		refreshTimeout = time.Duration(jwtHandler.RefreshTimeout)
+	}
+
+	// a login starts a session, refreshed tokens keep the session of the refresh token
+	if jwtHandler.SessionID == "" {
+		jwtHandler.SessionID = uuid.NewString()
+	}
+
 	claims = accessToken.Claims.(jwt.MapClaims)
 	claims["sub"] = jwtHandler.Sub
+	claims["typ"] = TypeAccess
 	claims["iss"] = jwtHandler.Iss
-	claims["exp"] = time.Now().Add(time.Hour * 200).Unix()
+	claims["aud"] = jwtHandler.Aud
+	claims["exp"] = time.Now().Add(accessTimeout).Unix()
 	claims["iat"] = time.Now().Unix()
 	claims["role"] = jwtHandler.Role
+	claims["tenant"] = jwtHandler.Tenant
+	claims["sid"] = jwtHandler.SessionID
+	claims["auth_time"] = jwtHandler.AuthTime
+	claims["acr"] = jwtHandler.Acr
+	// tokens issued to a third-party client on behalf of the user
+	if jwtHandler.ClientID != "" {
+		claims["client_id"] = jwtHandler.ClientID
+		claims["scope"] = strings.Join(jwtHandler.Scopes, " ")
+	}
 
 	access, err = accessToken.SignedString([]byte(jwtHandler.SigninKey))
 	if err != nil {
@@ -46,9 +165,19 @@ func (jwtHandler *JwtHandler) GenerateJwt() (access, refresh string, err error)
 
 	rtClaims := refreshToken.Claims.(jwt.MapClaims)
 	rtClaims["sub"] = jwtHandler.Sub
-	rtClaims["exp"] = time.Now().Add(time.Hour * 400).Unix()
+	rtClaims["typ"] = TypeRefresh
+	rtClaims["aud"] = jwtHandler.Aud
+	rtClaims["exp"] = time.Now().Add(refreshTimeout).Unix()
 	rtClaims["iat"] = time.Now().Unix()
 	rtClaims["role"] = jwtHandler.Role
+	rtClaims["tenant"] = jwtHandler.Tenant
+	rtClaims["sid"] = jwtHandler.SessionID
+	rtClaims["auth_time"] = jwtHandler.AuthTime
+	rtClaims["acr"] = jwtHandler.Acr
+	if jwtHandler.ClientID != "" {
+		rtClaims["client_id"] = jwtHandler.ClientID
+		rtClaims["scope"] = strings.Join(jwtHandler.Scopes, " ")
+	}
 
 	refresh, err = refreshToken.SignedString([]byte(jwtHandler.SigninKey))
 	if err != nil {
@@ -59,6 +188,74 @@ func (jwtHandler *JwtHandler) GenerateJwt() (access, refresh string, err error)
 	return access, refresh, nil
 }
 
+// GenerateClientJwt signs an access token for a service client, client tokens have no refresh token
+func (jwtHandler *JwtHandler) GenerateClientJwt() (string, error) {
+	accessToken := jwt.New(jwt.SigningMethodHS256)
+
+	accessTimeout := defaultAccessTimeout
+	if jwtHandler.Timeout > 0 {
+		accessTimeout = time.Duration(jwtHandler.Timeout)
+	}
+
+	claims := accessToken.Claims.(jwt.MapClaims)
+	claims["sub"] = jwtHandler.ClientID
+	claims["typ"] = TypeAccess
+	claims["client_id"] = jwtHandler.ClientID
+	claims["scope"] = strings.Join(jwtHandler.Scopes, " ")
+	claims["tenant"] = jwtHandler.Tenant
+	claims["aud"] = jwtHandler.Aud
+	claims["exp"] = time.Now().Add(accessTimeout).Unix()
+	claims["iat"] = time.Now().Unix()
+
+	access, err := accessToken.SignedString([]byte(jwtHandler.SigninKey))
+	if err != nil {
+		jwtHandler.Log.Error("error generating client access token", logger.Error(err))
+		return "", err
+	}
+
+	return access, nil
+}
+
+// resetKey derives the key of reset tokens from the signin key, a reset token
+// is then never valid where access or refresh tokens are expected
+func resetKey(signinKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(signinKey))
+	mac.Write([]byte(TypeReset))
+	return mac.Sum(nil)
+}
+
+// GenerateResetJwt signs a password reset token bound to the reset record jti
+func (jwtHandler *JwtHandler) GenerateResetJwt(jti string, ttl time.Duration) (string, error) {
+	resetToken := jwt.New(jwt.SigningMethodHS256)
+
+	claims := resetToken.Claims.(jwt.MapClaims)
+	claims["sub"] = jwtHandler.Sub
+	claims["jti"] = jti
+	claims["typ"] = TypeReset
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	claims["iat"] = time.Now().Unix()
+
+	reset, err := resetToken.SignedString(resetKey(jwtHandler.SigninKey))
+	if err != nil {
+		jwtHandler.Log.Error("error generating reset token", logger.Error(err))
+		return "", err
+	}
+
+	return reset, nil
+}
+
+// ExtractResetClaim returns the claims of a password reset token
+func ExtractResetClaim(tokenStr, signinKey string) (jwt.MapClaims, error) {
+	claims, err := ExtractClaim(tokenStr, resetKey(signinKey))
+	if err != nil {
+		return nil, err
+	}
+	if !HasType(claims, TypeReset) {
+		return nil, fmt.Errorf("token of type %q is not a reset token", claims["typ"])
+	}
+	return claims, nil
+}
+
 func ExtractClaim(tokenStr string, signingKey []byte) (jwt.MapClaims, error) {
 	var (
 		token *jwt.Token
@@ -80,4 +277,4 @@ func ExtractClaim(tokenStr string, signingKey []byte) (jwt.MapClaims, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
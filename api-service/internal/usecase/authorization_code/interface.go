@@ -0,0 +1,18 @@
+package authorization_code
+
+import (
+	"context"
+	"time"
+
+	"medods/api-service/internal/entity"
+)
+
+type AuthorizationCode interface {
+	Create(ctx context.Context, m *entity.AuthorizationCode) (string, error)
+	Exchange(ctx context.Context, code, clientID, redirectURI, codeVerifier string) (*entity.AuthorizationCode, error)
+}
+
+type AuthorizationCodeRepo interface {
+	Create(ctx context.Context, m *entity.AuthorizationCode) error
+	MarkUsed(ctx context.Context, codeHash string, usedAt time.Time) (*entity.AuthorizationCode, error)
+}
@@ -0,0 +1,91 @@
+package authorization_code
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"medods/api-service/internal/entity"
+)
+
+const CodeChallengeMethodS256 = "S256"
+
+var ErrInvalidGrant = errors.New("authorization code is invalid, expired or was issued to another client")
+
+type authorizationCodeService struct {
+	ctxTimeout time.Duration
+	repo       AuthorizationCodeRepo
+}
+
+func NewAuthorizationCodeService(ctxTimeout time.Duration, repo AuthorizationCodeRepo) AuthorizationCode {
+	return &authorizationCodeService{
+		ctxTimeout: ctxTimeout,
+		repo:       repo,
+	}
+}
+
+func (r *authorizationCodeService) beforeCreate(m *entity.AuthorizationCode) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	// only the hash of the code is stored
+	m.CodeHash = hashCode(code)
+	m.CreatedAt = time.Now().UTC()
+	return code, nil
+}
+
+func (r *authorizationCodeService) Create(ctx context.Context, m *entity.AuthorizationCode) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	code, err := r.beforeCreate(m)
+	if err != nil {
+		return "", err
+	}
+	if err := r.repo.Create(ctx, m); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Exchange consumes the code before validating it, so a code can never be tried twice
+func (r *authorizationCodeService) Exchange(ctx context.Context, code, clientID, redirectURI, codeVerifier string) (*entity.AuthorizationCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	m, err := r.repo.MarkUsed(ctx, hashCode(code), time.Now().UTC())
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	if m.ClientID != clientID || m.RedirectURI != redirectURI {
+		return nil, ErrInvalidGrant
+	}
+	if !VerifyCodeChallenge(m.CodeChallenge, m.CodeChallengeMethod, codeVerifier) {
+		return nil, ErrInvalidGrant
+	}
+	return m, nil
+}
+
+// VerifyCodeChallenge checks a PKCE code verifier, only S256 is supported
+func VerifyCodeChallenge(challenge, method, verifier string) bool {
+	if method != CodeChallengeMethodS256 || challenge == "" || verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+
+	"medods/api-service/internal/entity"
+)
+
+type Client interface {
+	Get(ctx context.Context, id string) (*entity.Client, error)
+	Create(ctx context.Context, m *entity.Client, secret string) error
+	Authenticate(ctx context.Context, id, secret string) (*entity.Client, error)
+}
+
+type ClientRepo interface {
+	Get(ctx context.Context, id string) (*entity.Client, error)
+	Create(ctx context.Context, m *entity.Client) error
+}
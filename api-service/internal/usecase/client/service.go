@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"medods/api-service/internal/entity"
+	"medods/api-service/internal/pkg/tracing"
+)
+
+var ErrInvalidClient = errors.New("invalid client credentials")
+
+type clientService struct {
+	ctxTimeout time.Duration
+	repo       ClientRepo
+}
+
+func NewClientService(ctxTimeout time.Duration, repo ClientRepo) Client {
+	return &clientService{
+		ctxTimeout: ctxTimeout,
+		repo:       repo,
+	}
+}
+
+func (r *clientService) beforeCreate(m *entity.Client, secret string) error {
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	m.SecretHash = string(secretHash)
+	m.CreatedAt = time.Now().UTC()
+	m.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (r *clientService) Get(ctx context.Context, id string) (*entity.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	return r.repo.Get(ctx, id)
+}
+
+func (r *clientService) Create(ctx context.Context, m *entity.Client, secret string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	if err := r.beforeCreate(m, secret); err != nil {
+		return err
+	}
+	return r.repo.Create(ctx, m)
+}
+
+// Authenticate returns ErrInvalidClient for both unknown clients and wrong secrets
+func (r *clientService) Authenticate(ctx context.Context, id, secret string) (*entity.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	client, err := r.repo.Get(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	err = bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
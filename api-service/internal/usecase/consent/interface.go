@@ -0,0 +1,18 @@
+package consent
+
+import (
+	"context"
+
+	"medods/api-service/internal/entity"
+)
+
+type Consent interface {
+	Get(ctx context.Context, userID, clientID string) (*entity.Consent, error)
+	Grant(ctx context.Context, m *entity.Consent) error
+	Covers(ctx context.Context, userID, clientID string, scopes []string) (bool, error)
+}
+
+type ConsentRepo interface {
+	Get(ctx context.Context, userID, clientID string) (*entity.Consent, error)
+	Upsert(ctx context.Context, m *entity.Consent) error
+}
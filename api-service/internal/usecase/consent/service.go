@@ -0,0 +1,86 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"medods/api-service/internal/entity"
+	errorspkg "medods/api-service/internal/errors"
+)
+
+type consentService struct {
+	ctxTimeout time.Duration
+	repo       ConsentRepo
+}
+
+func NewConsentService(ctxTimeout time.Duration, repo ConsentRepo) Consent {
+	return &consentService{
+		ctxTimeout: ctxTimeout,
+		repo:       repo,
+	}
+}
+
+func (r *consentService) beforeGrant(m *entity.Consent) {
+	m.CreatedAt = time.Now().UTC()
+	m.UpdatedAt = time.Now().UTC()
+}
+
+func (r *consentService) Get(ctx context.Context, userID, clientID string) (*entity.Consent, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	return r.repo.Get(ctx, userID, clientID)
+}
+
+// Grant adds scopes to the consent the user already gave to the client
+func (r *consentService) Grant(ctx context.Context, m *entity.Consent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	existing, err := r.repo.Get(ctx, m.UserID, m.ClientID)
+	if err != nil && !errors.Is(err, errorspkg.ErrorNotFound) {
+		return err
+	}
+	if existing != nil {
+		m.Scopes = mergeScopes(existing.Scopes, m.Scopes)
+	}
+
+	r.beforeGrant(m)
+	return r.repo.Upsert(ctx, m)
+}
+
+func (r *consentService) Covers(ctx context.Context, userID, clientID string, scopes []string) (bool, error) {
+	m, err := r.Get(ctx, userID, clientID)
+	if errors.Is(err, errorspkg.ErrorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	granted := make(map[string]bool, len(m.Scopes))
+	for _, scope := range m.Scopes {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func mergeScopes(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	res := make([]string, 0, len(a)+len(b))
+	for _, scopes := range [][]string{a, b} {
+		for _, scope := range scopes {
+			if !seen[scope] {
+				seen[scope] = true
+				res = append(res, scope)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,20 @@
+package password_reset
+
+import (
+	"context"
+	"time"
+
+	"medods/api-service/internal/entity"
+)
+
+type PasswordReset interface {
+	Get(ctx context.Context, guid string) (*entity.PasswordReset, error)
+	Create(ctx context.Context, m *entity.PasswordReset) error
+	Use(ctx context.Context, guid string) (*entity.PasswordReset, error)
+}
+
+type PasswordResetRepo interface {
+	Get(ctx context.Context, guid string) (*entity.PasswordReset, error)
+	Create(ctx context.Context, m *entity.PasswordReset) error
+	MarkUsed(ctx context.Context, guid string, usedAt time.Time) (*entity.PasswordReset, error)
+}
@@ -0,0 +1,50 @@
+package password_reset
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"medods/api-service/internal/entity"
+)
+
+type passwordResetService struct {
+	ctxTimeout time.Duration
+	repo       PasswordResetRepo
+}
+
+func NewPasswordResetService(ctxTimeout time.Duration, repo PasswordResetRepo) PasswordReset {
+	return &passwordResetService{
+		ctxTimeout: ctxTimeout,
+		repo:       repo,
+	}
+}
+
+func (r *passwordResetService) beforeCreate(m *entity.PasswordReset) {
+	m.GUID = uuid.New().String()
+	m.CreatedAt = time.Now().UTC()
+}
+
+func (r *passwordResetService) Get(ctx context.Context, guid string) (*entity.PasswordReset, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	return r.repo.Get(ctx, guid)
+}
+
+func (r *passwordResetService) Create(ctx context.Context, m *entity.PasswordReset) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	r.beforeCreate(m)
+	return r.repo.Create(ctx, m)
+}
+
+// Use consumes the reset record, it succeeds only once and only before expiry
+func (r *passwordResetService) Use(ctx context.Context, guid string) (*entity.PasswordReset, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
+	defer cancel()
+
+	return r.repo.MarkUsed(ctx, guid, time.Now().UTC())
+}
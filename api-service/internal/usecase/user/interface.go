@@ -0,0 +1,17 @@
+package user
+
+import (
+	"context"
+
+	"medods/api-service/internal/entity"
+)
+
+// UserStore keeps the user accounts, it is backed either by user-service over
+// grpc or directly by the users table
+type UserStore interface {
+	Get(ctx context.Context, filter *entity.UserFilter) (*entity.User, error)
+	Create(ctx context.Context, m *entity.User) error
+	// Update changes the non-empty fields of m
+	Update(ctx context.Context, m *entity.User) error
+	Delete(ctx context.Context, filter *entity.UserFilter) error
+}
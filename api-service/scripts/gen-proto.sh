@@ -1,18 +1,10 @@
 #!/bin/bash
-CURRENT_DIR=$(pwd)
+# generates genproto/ from protos/ with buf, protoc-gen-go and protoc-gen-go-grpc must be in PATH
+set -e
 
-for module in $(find $CURRENT_DIR/protos/* -type d); do
-    protoc -I /usr/local/include \
-           -I $GOPATH/pkg/mod/github.com/gogo/protobuf@v1.3.2 \
-           -I $CURRENT_DIR/protos/ \
-            --gofast_out=plugins=grpc:$CURRENT_DIR/genproto/ \
-            $module/*.proto;
-done;
+CURRENT_DIR=$(cd "$(dirname "$0")/.." && pwd)
 
-for module in $(find $CURRENT_DIR/genproto/* -type d); do
-  if [[ "$OSTYPE" == "darwin"* ]]; then
-    sed -i "" -e "s/,omitempty//g" $module/*.go
-  else
-    sed -i -e "s/,omitempty//g" $module/*.go
-  fi
-done;
\ No newline at end of file
+cd $CURRENT_DIR
+rm -rf genproto
+buf lint
+buf generate
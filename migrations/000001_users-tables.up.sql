@@ -1,4 +1,4 @@
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY,
-    refresh_token TEXT,
+    refresh_token TEXT
 );
\ No newline at end of file
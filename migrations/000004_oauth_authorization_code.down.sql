@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+ALTER TABLE clients DROP COLUMN IF EXISTS redirect_uris;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS casbin_rule;
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS casbin_rule (
+    id TEXT PRIMARY KEY,
+    p_type TEXT,
+    v0 TEXT,
+    v1 TEXT,
+    v2 TEXT,
+    v3 TEXT,
+    v4 TEXT,
+    v5 TEXT
+);
+
+-- rules of api-service/auth.csv, ids are the checksums the pgx adapter uses to address a rule
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('a98580e61b80ef56e2397e7b8e76befd', 'p', 'unauthorized', '/v1/swagger/*', 'GET', NULL, NULL, NULL),
+    ('caaae6cef070be059aaee36445fa7e76', 'p', 'unauthorized', '/v1/users/register', 'POST', NULL, NULL, NULL),
+    ('849c6ae79a345ebef34f479f2e73e3c4', 'p', 'unauthorized', '/v1/users/verify', 'GET', NULL, NULL, NULL),
+    ('e6cfc92f86a1d77bc35c088b91161e01', 'p', 'unauthorized', '/v1/users/login', 'POST', NULL, NULL, NULL),
+    ('124891c1447b2fb06f95f90ef89e76ec', 'p', 'unauthorized', '/v1/admins/login', 'POST', NULL, NULL, NULL),
+    ('4674463a9ebb819c72296a292f3e4675', 'p', 'unauthorized', '/v1/users/set/{email}', 'GET', NULL, NULL, NULL),
+    ('41df7e7f4bb66834bf0621ce62b47eaa', 'p', 'unauthorized', '/v1/users/code', 'GET', NULL, NULL, NULL),
+    ('19a28dfc12abfe79d84e16d6f56be8c7', 'p', 'unauthorized', '/v1/users/password', 'PUT', NULL, NULL, NULL),
+    ('057333264678e001cd2913e55a420da6', 'p', 'unauthorized', '/v1/token/:refresh', 'GET', NULL, NULL, NULL),
+    ('c3309f68fe8caec767d83dbcba919491', 'p', 'unauthorized', '/v1/oauth/token', 'POST', NULL, NULL, NULL),
+    ('a095ab107f2c988fcaeb29dbd5f865e0', 'p', 'unauthorized', '/v1/oauth/jwks', 'GET', NULL, NULL, NULL),
+    ('7ddb0a5f884d1fe0867fdfc27cac70bc', 'p', 'unauthorized', '/.well-known/openid-configuration', 'GET', NULL, NULL, NULL),
+    ('a790998ffecc31812975f63a94cad055', 'p', 'user', '/v1/users/{id}', 'GET', NULL, NULL, NULL),
+    ('435765083a4a982ff8b55a43824b8616', 'p', 'user', '/v1/users', 'PUT', NULL, NULL, NULL),
+    ('5a12fa1a54f1951024c7f1653bcdd61a', 'p', 'user', '/v1/media/user-photo', 'POST', NULL, NULL, NULL),
+    ('2b4a0122abda9d2fc173fedd5f5f18be', 'p', 'user', '/v1/oauth/authorize', 'GET', NULL, NULL, NULL),
+    ('daa261752d0438fa4113c1a76f6a1665', 'p', 'user', '/v1/oauth/authorize', 'POST', NULL, NULL, NULL),
+    ('a9a448fefb1a464289290e2a72a662fb', 'p', 'user', '/v1/oauth/userinfo', 'GET', NULL, NULL, NULL),
+    ('e442f299596c9b08a4dd1ff3478df084', 'p', 'admin', '/v1/users', 'POST', NULL, NULL, NULL),
+    ('91d2f8b3447a9b7abbadaf3be0d42849', 'p', 'admin', '/v1/users/list', 'GET', NULL, NULL, NULL),
+    ('61bf3effbf8664ba6006cdf2c1ed2f93', 'p', 'admin', '/v1/users/list/deleted', 'GET', NULL, NULL, NULL),
+    ('875a11f779bd950930303f99112ca278', 'p', 'admin', '/v1/users/{id}', 'DELETE', NULL, NULL, NULL),
+    ('0525bd1900b59e3d0c0add1e0945351d', 'g', 'admin', 'user', '*', NULL, NULL, NULL),
+    ('88b27cbe8bc687b0f913099e5755f9af', 'g', 'admin', 'unauthorized', '*', NULL, NULL, NULL),
+    ('9cca478e527ff6377636e0a12f055d29', 'p2', '/v1/users/{id}', 'DELETE', '5m', 'pwd', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
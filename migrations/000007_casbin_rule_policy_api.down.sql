@@ -0,0 +1,14 @@
+DELETE FROM casbin_rule WHERE id IN (
+    'f94f12ba02c510f22ea16230eb8c3759',
+    '5f3408e58a3a6daf3e0619a61d1ed4be',
+    'dfecfb7043555218aecbd07c37c633dd',
+    '3c03cf39c2def667ebc8c2ce79eb2971',
+    'a2abb4f338370f47d9f1a4b6de53a21d',
+    'b48d2324a6851d3395b1dc56900978ba',
+    '527c632b955d24b4e127b1c151c7c97b',
+    '9c1d9652ea716e48585cea53070e35b5',
+    '70a35f9b1e3946f071866055fd4cbd0e',
+    '7a6abbc8af149077414c5e705cd9ce6d',
+    '420da7d20e1ad52a628c5b2ec9e69820',
+    '5c898165e42ada169d53f3eb35ec0da7'
+);
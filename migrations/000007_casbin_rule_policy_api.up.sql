@@ -0,0 +1,14 @@
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('f94f12ba02c510f22ea16230eb8c3759', 'p', 'admin', '/v1/policies', 'GET', NULL, NULL, NULL),
+    ('5f3408e58a3a6daf3e0619a61d1ed4be', 'p', 'admin', '/v1/policies', 'POST', NULL, NULL, NULL),
+    ('dfecfb7043555218aecbd07c37c633dd', 'p', 'admin', '/v1/policies', 'DELETE', NULL, NULL, NULL),
+    ('3c03cf39c2def667ebc8c2ce79eb2971', 'p', 'admin', '/v1/policies/check', 'GET', NULL, NULL, NULL),
+    ('a2abb4f338370f47d9f1a4b6de53a21d', 'p', 'admin', '/v1/policies/roles', 'GET', NULL, NULL, NULL),
+    ('b48d2324a6851d3395b1dc56900978ba', 'p', 'admin', '/v1/policies/roles', 'POST', NULL, NULL, NULL),
+    ('527c632b955d24b4e127b1c151c7c97b', 'p', 'admin', '/v1/policies/roles', 'DELETE', NULL, NULL, NULL),
+    ('9c1d9652ea716e48585cea53070e35b5', 'p', 'admin', '/v1/policies/roles/{subject}', 'GET', NULL, NULL, NULL),
+    ('70a35f9b1e3946f071866055fd4cbd0e', 'p2', '/v1/policies', 'POST', '5m', 'pwd', NULL, NULL),
+    ('7a6abbc8af149077414c5e705cd9ce6d', 'p2', '/v1/policies', 'DELETE', '5m', 'pwd', NULL, NULL),
+    ('420da7d20e1ad52a628c5b2ec9e69820', 'p2', '/v1/policies/roles', 'POST', '5m', 'pwd', NULL, NULL),
+    ('5c898165e42ada169d53f3eb35ec0da7', 'p2', '/v1/policies/roles', 'DELETE', '5m', 'pwd', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,6 @@
+-- object patterns are matched with keyMatch3 only, which uses {param} segments
+DELETE FROM casbin_rule WHERE id = '057333264678e001cd2913e55a420da6';
+
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('478034f5278ba3ce1153e032a383bc62', 'p', 'unauthorized', '/v1/token/{refresh}', 'GET', NULL, NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,5 @@
+DELETE FROM casbin_rule WHERE id IN ('55d1cafcabce69f78770d830b48148bb', 'f2e3d791aef8c0b58a8303980ff9c422');
+
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('a790998ffecc31812975f63a94cad055', 'p', 'user', '/v1/users/{id}', 'GET', NULL, NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
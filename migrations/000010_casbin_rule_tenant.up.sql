@@ -0,0 +1,41 @@
+-- policies get a tenant column, the seeded rules are shared by every tenant
+DELETE FROM casbin_rule WHERE id IN ('a98580e61b80ef56e2397e7b8e76befd', 'caaae6cef070be059aaee36445fa7e76', '849c6ae79a345ebef34f479f2e73e3c4', 'e6cfc92f86a1d77bc35c088b91161e01', '124891c1447b2fb06f95f90ef89e76ec', '4674463a9ebb819c72296a292f3e4675', '41df7e7f4bb66834bf0621ce62b47eaa', '19a28dfc12abfe79d84e16d6f56be8c7', '478034f5278ba3ce1153e032a383bc62', 'c3309f68fe8caec767d83dbcba919491', 'a095ab107f2c988fcaeb29dbd5f865e0', '7ddb0a5f884d1fe0867fdfc27cac70bc', '55d1cafcabce69f78770d830b48148bb', 'f2e3d791aef8c0b58a8303980ff9c422', '435765083a4a982ff8b55a43824b8616', '5a12fa1a54f1951024c7f1653bcdd61a', '2b4a0122abda9d2fc173fedd5f5f18be', 'daa261752d0438fa4113c1a76f6a1665', 'a9a448fefb1a464289290e2a72a662fb', 'e442f299596c9b08a4dd1ff3478df084', '91d2f8b3447a9b7abbadaf3be0d42849', '61bf3effbf8664ba6006cdf2c1ed2f93', '875a11f779bd950930303f99112ca278', 'f94f12ba02c510f22ea16230eb8c3759', '5f3408e58a3a6daf3e0619a61d1ed4be', 'dfecfb7043555218aecbd07c37c633dd', '3c03cf39c2def667ebc8c2ce79eb2971', 'a2abb4f338370f47d9f1a4b6de53a21d', 'b48d2324a6851d3395b1dc56900978ba', '527c632b955d24b4e127b1c151c7c97b', '9c1d9652ea716e48585cea53070e35b5');
+
+-- rules and role grants added through the policy api belong to the default tenant,
+-- the ids can not be computed here and are recomputed by the service on start
+UPDATE casbin_rule SET v3 = v2, v2 = v1, v1 = 'default' WHERE p_type = 'p' AND v3 IS NULL;
+UPDATE casbin_rule SET v2 = 'default' WHERE p_type = 'g' AND v2 IS NULL;
+
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('5c8ad58f6ad786b221ee1a0a271407ba', 'p', 'unauthorized', '*', '/v1/swagger/*', 'GET', NULL, NULL),
+    ('5ba94b450eea237a24a702bdd34e41f4', 'p', 'unauthorized', '*', '/v1/users/register', 'POST', NULL, NULL),
+    ('725c0f8d6123fdeae53dcfc4d68004ae', 'p', 'unauthorized', '*', '/v1/users/verify', 'GET', NULL, NULL),
+    ('aa6e027e6da71d0827583353d5a91c81', 'p', 'unauthorized', '*', '/v1/users/login', 'POST', NULL, NULL),
+    ('8878dbb8f1ec4d24279920b00c4e3b82', 'p', 'unauthorized', '*', '/v1/admins/login', 'POST', NULL, NULL),
+    ('d438d87ae0a3bed9fa1393f7a8f926ee', 'p', 'unauthorized', '*', '/v1/users/set/{email}', 'GET', NULL, NULL),
+    ('b53e049638d181667ef7174750be48be', 'p', 'unauthorized', '*', '/v1/users/code', 'GET', NULL, NULL),
+    ('09bc241f2df9cd379504fdb622488dce', 'p', 'unauthorized', '*', '/v1/users/password', 'PUT', NULL, NULL),
+    ('7b4c47e815f1fb19099a9bf61b2fe27e', 'p', 'unauthorized', '*', '/v1/token/{refresh}', 'GET', NULL, NULL),
+    ('3af08fa554421d5ec9ff18e77b3c33cc', 'p', 'unauthorized', '*', '/v1/oauth/token', 'POST', NULL, NULL),
+    ('970d2ec4bb349358fb08bb2515f52c90', 'p', 'unauthorized', '*', '/v1/oauth/jwks', 'GET', NULL, NULL),
+    ('0de7febd27a05f9d4844a7175761bcbc', 'p', 'unauthorized', '*', '/.well-known/openid-configuration', 'GET', NULL, NULL),
+    ('e1487e8aedd6b67d0a5b554b54c4c4bd', 'p', 'owner', '*', '/v1/users/{id}', 'GET', NULL, NULL),
+    ('63b8d6b16f7a0f8bb06eb8305909000f', 'p', 'admin', '*', '/v1/users/{id}', 'GET', NULL, NULL),
+    ('6762b2ddbff61b149b3b16e1134bc9b9', 'p', 'user', '*', '/v1/users', 'PUT', NULL, NULL),
+    ('9d0d7650034279259520b781ed7a0620', 'p', 'user', '*', '/v1/media/user-photo', 'POST', NULL, NULL),
+    ('9d94417568ba66a85be33195b222a9f7', 'p', 'user', '*', '/v1/oauth/authorize', 'GET', NULL, NULL),
+    ('ea895773ba8560090c227fc29fc2ec79', 'p', 'user', '*', '/v1/oauth/authorize', 'POST', NULL, NULL),
+    ('64e40c5e3e48e9fe05e76c021b7d749a', 'p', 'user', '*', '/v1/oauth/userinfo', 'GET', NULL, NULL),
+    ('ff4d907ad6cfb270d8d85c61b91d58f0', 'p', 'admin', '*', '/v1/users', 'POST', NULL, NULL),
+    ('6a901aa1f64a1e79452535e4c5d3e90d', 'p', 'admin', '*', '/v1/users/list', 'GET', NULL, NULL),
+    ('d20543ad851d5ef878aa9812a79250ac', 'p', 'admin', '*', '/v1/users/list/deleted', 'GET', NULL, NULL),
+    ('83460edbd7a93efa139e6788d342a323', 'p', 'admin', '*', '/v1/users/{id}', 'DELETE', NULL, NULL),
+    ('b3f678ac224d0d6e73118f8c5517e120', 'p', 'admin', '*', '/v1/policies', 'GET', NULL, NULL),
+    ('6b5927fe785ccad9085e50be89eafc17', 'p', 'admin', '*', '/v1/policies', 'POST', NULL, NULL),
+    ('e701a3295f9156f83d3e99cf36a0961b', 'p', 'admin', '*', '/v1/policies', 'DELETE', NULL, NULL),
+    ('5fd159eb7458d7cbbe65c2dd94590520', 'p', 'admin', '*', '/v1/policies/check', 'GET', NULL, NULL),
+    ('421e836bca6af2574431fcd42cd978a0', 'p', 'admin', '*', '/v1/policies/roles', 'GET', NULL, NULL),
+    ('5aac28597b4dc7cfbee8df9655ace58a', 'p', 'admin', '*', '/v1/policies/roles', 'POST', NULL, NULL),
+    ('2df13f29348d756b29104c6e60b86b3a', 'p', 'admin', '*', '/v1/policies/roles', 'DELETE', NULL, NULL),
+    ('c1fe542fb5f381c7bc9e079648f21376', 'p', 'admin', '*', '/v1/policies/roles/{subject}', 'GET', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
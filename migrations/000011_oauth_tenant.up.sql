@@ -0,0 +1 @@
+ALTER TABLE oauth_authorization_codes ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
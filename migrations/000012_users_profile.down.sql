@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS users_email_key;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS full_name,
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS password,
+    DROP COLUMN IF EXISTS date_of_birth,
+    DROP COLUMN IF EXISTS profile_img,
+    DROP COLUMN IF EXISTS card,
+    DROP COLUMN IF EXISTS gender,
+    DROP COLUMN IF EXISTS phone_number,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS full_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS password TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS date_of_birth TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS profile_img TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS card TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS gender TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS phone_number TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user',
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE deleted_at IS NULL AND email <> '';
@@ -0,0 +1 @@
+DELETE FROM casbin_rule WHERE id = '4286ae22529fbe4cd4af9c4093844266';
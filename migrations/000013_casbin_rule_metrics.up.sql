@@ -0,0 +1,4 @@
+-- metrics are scraped by service clients granted the monitoring role
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('4286ae22529fbe4cd4af9c4093844266', 'p', 'monitoring', '*', '/metrics', 'GET', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM casbin_rule WHERE id IN ('651bf2f0997e177938f28bd3d7301f75', '591ac7bf2b64fadb305c98f460df9c07');
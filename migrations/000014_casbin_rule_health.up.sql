@@ -0,0 +1,5 @@
+-- probes of the orchestrator are not authenticated
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('651bf2f0997e177938f28bd3d7301f75', 'p', 'unauthorized', '*', '/healthz', 'GET', NULL, NULL),
+    ('591ac7bf2b64fadb305c98f460df9c07', 'p', 'unauthorized', '*', '/readyz', 'GET', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
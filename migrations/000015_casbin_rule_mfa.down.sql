@@ -0,0 +1 @@
+DELETE FROM casbin_rule WHERE id = 'a2942df99a0e309625ba71774de0c7c8';
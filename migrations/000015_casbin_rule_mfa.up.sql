@@ -0,0 +1,4 @@
+-- listing deleted users requires a recent multi-factor login
+INSERT INTO casbin_rule (id, p_type, v0, v1, v2, v3, v4, v5) VALUES
+    ('a2942df99a0e309625ba71774de0c7c8', 'p2', '/v1/users/list/deleted', 'GET', '5m', 'mfa', NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
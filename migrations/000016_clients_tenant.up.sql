@@ -0,0 +1,2 @@
+-- service clients act in their tenant, the default tenant when empty
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '';